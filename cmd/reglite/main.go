@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -12,15 +13,33 @@ import (
 	"github.com/reglite/reglite/internal/handlers"
 )
 
+// stringList флаг, который можно указать несколько раз
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *stringList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
 func main() {
+	var dockerConfigs stringList
 	var (
-		configFile = flag.String("config", "inventory.yaml", "Path to config file")
-		port       = flag.String("port", "8080", "Port to listen on")
-		debug      = flag.Bool("debug", false, "Enable debug mode")
+		configFile  = flag.String("config", "inventory.yaml", "Path to config file")
+		port        = flag.String("port", "8080", "Port to listen on")
+		debug       = flag.Bool("debug", false, "Enable debug mode")
+		noDiscovery = flag.Bool("no-auth-discovery", false, "Do not read docker/podman auth files from default locations")
 	)
+	flag.Var(&dockerConfigs, "docker-config", "Docker config.json, containers auth.json or directory with config.json (repeatable, first has highest priority)")
 	flag.Parse()
 
-	cfg, err := config.LoadConfig(*configFile)
+	cfg, err := config.LoadConfig(*configFile, config.AuthOptions{
+		Files:            dockerConfigs,
+		DisableDiscovery: *noDiscovery,
+	})
 	if err != nil {
 		log.Fatalf("Failed to load config: %v", err)
 	}
@@ -49,6 +68,15 @@ func main() {
 		api.GET("/tags", h.GetTags)
 		api.GET("/manifest", h.GetManifest)
 		api.DELETE("/manifest", h.DeleteTag)
+		api.GET("/referrers", h.GetReferrers)
+		api.GET("/config", h.GetImageConfig)
+		api.GET("/history", h.GetImageHistory)
+		api.GET("/layer/files", h.GetLayerFiles)
+		api.GET("/filesystem", h.GetFilesystem)
+		api.GET("/file", h.GetFile)
+		api.GET("/diff", h.GetImageDiff)
+		api.POST("/storage/analysis", h.StartStorageAnalysis)
+		api.GET("/storage/analysis", h.GetStorageAnalysis)
 	}
 	server := &http.Server{
 		Addr:           ":" + *port,
@@ -60,11 +88,19 @@ func main() {
 
 	log.Printf("🚀 RegLite starting on :%s", *port)
 	log.Printf("📖 Loaded %d registries from %s", len(cfg.Inventory), *configFile)
+	for _, authFile := range cfg.AuthFiles {
+		log.Printf("🔑 Credentials from %s", authFile)
+	}
+	for _, warning := range cfg.Warnings {
+		log.Printf("⚠️  %s", warning)
+	}
 	for name, registry := range cfg.Inventory {
 		username, _, err := registry.GetCredentials()
 		authInfo := "no auth"
 		if err == nil && username != "" {
 			authInfo = fmt.Sprintf("auth: %s", username)
+		} else if registry.IdentityToken != "" || registry.RegistryToken != "" {
+			authInfo = "auth: token"
 		}
 		log.Printf("   • %s → %s (%s)", name, registry.URL, authInfo)
 	}
@@ -0,0 +1,102 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// AuthOptions источники учетных данных Docker и Podman
+type AuthOptions struct {
+	Files            []string // Явно заданные файлы или каталоги с config.json, по убыванию приоритета
+	DisableDiscovery bool     // Не искать файлы учетных данных и daemon.json в стандартных расположениях
+}
+
+// authFilePaths возвращает файлы учетных данных по убыванию приоритета: явно заданные,
+// REGISTRY_AUTH_FILE, $XDG_RUNTIME_DIR/containers/auth.json,
+// $XDG_CONFIG_HOME/containers/auth.json, $DOCKER_CONFIG/config.json или ~/.docker/config.json
+func authFilePaths(opts AuthOptions) []string {
+	paths := make([]string, 0, len(opts.Files)+4)
+	for _, file := range opts.Files {
+		paths = append(paths, dockerConfigFile(file))
+	}
+
+	if opts.DisableDiscovery {
+		return paths
+	}
+
+	if authFile := os.Getenv("REGISTRY_AUTH_FILE"); authFile != "" {
+		paths = append(paths, authFile)
+	}
+	if runtimeDir := os.Getenv("XDG_RUNTIME_DIR"); runtimeDir != "" {
+		paths = append(paths, filepath.Join(runtimeDir, "containers", "auth.json"))
+	}
+
+	homeDir, _ := os.UserHomeDir()
+	if configHome := os.Getenv("XDG_CONFIG_HOME"); configHome != "" {
+		paths = append(paths, filepath.Join(configHome, "containers", "auth.json"))
+	} else if homeDir != "" {
+		paths = append(paths, filepath.Join(homeDir, ".config", "containers", "auth.json"))
+	}
+
+	if dockerConfig := os.Getenv("DOCKER_CONFIG"); dockerConfig != "" {
+		paths = append(paths, filepath.Join(dockerConfig, "config.json"))
+	} else if homeDir != "" {
+		paths = append(paths, filepath.Join(homeDir, ".docker", "config.json"))
+	}
+
+	return paths
+}
+
+// dockerConfigFile для каталога (как DOCKER_CONFIG) возвращает путь к config.json в нем
+func dockerConfigFile(path string) string {
+	if info, err := os.Stat(path); err == nil && info.IsDir() {
+		return filepath.Join(path, "config.json")
+	}
+	return path
+}
+
+// loadAuthFiles загружает файлы учетных данных по убыванию приоритета. Отсутствующие
+// и некорректные файлы из стандартных расположений пропускаются, явно заданные обязательны
+func loadAuthFiles(opts AuthOptions) ([]string, []*DockerConfig, error) {
+	var loaded []string
+	var dockerConfigs []*DockerConfig
+	seen := make(map[string]bool)
+
+	for i, path := range authFilePaths(opts) {
+		if seen[path] {
+			continue
+		}
+		seen[path] = true
+
+		dockerConfig, err := LoadDockerConfig(path)
+		if err != nil {
+			if i >= len(opts.Files) {
+				continue
+			}
+			return nil, nil, fmt.Errorf("failed to load docker config %s: %w", path, err)
+		}
+
+		loaded = append(loaded, path)
+		dockerConfigs = append(dockerConfigs, dockerConfig)
+	}
+
+	return loaded, dockerConfigs, nil
+}
+
+// LoadDockerConfig загружает файл учетных данных в формате Docker config.json
+// (containers auth.json использует тот же формат)
+func LoadDockerConfig(path string) (*DockerConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var dockerConfig DockerConfig
+	if err := json.Unmarshal(data, &dockerConfig); err != nil {
+		return nil, err
+	}
+
+	return &dockerConfig, nil
+}
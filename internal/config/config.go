@@ -2,43 +2,92 @@ package config
 
 import (
 	"encoding/base64"
-	"encoding/json"
 	"fmt"
 	"os"
-	"path/filepath"
+	"path"
 	"strings"
+	"time"
 
 	"gopkg.in/yaml.v3"
 )
 
 type Registry struct {
-	URL      string `yaml:"url"`
-	Username string `yaml:"username,omitempty"`
-	Password string `yaml:"password,omitempty"`
-	Auth     string `yaml:"auth,omitempty"` // base64 encoded username:password
+	URL           string `yaml:"url"`
+	Username      string `yaml:"username,omitempty"`
+	Password      string `yaml:"password,omitempty"`
+	PasswordEnv   string `yaml:"password_env,omitempty"`   // Переменная окружения с паролем
+	PasswordFile  string `yaml:"password_file,omitempty"`  // Файл с паролем, например /run/secrets/registry
+	Auth          string `yaml:"auth,omitempty"`           // base64 encoded username:password
+	IdentityToken string `yaml:"identity_token,omitempty"` // refresh token для обмена на bearer токен
+	RegistryToken string `yaml:"registry_token,omitempty"` // bearer токен, передается реестру напрямую
+
+	CAFile             string `yaml:"ca_file,omitempty"`              // PEM с корневыми сертификатами в дополнение к системным
+	CertFile           string `yaml:"cert_file,omitempty"`            // Клиентский сертификат для mTLS
+	KeyFile            string `yaml:"key_file,omitempty"`             // Ключ клиентского сертификата
+	InsecureSkipVerify bool   `yaml:"insecure_skip_verify,omitempty"` // Не проверять сертификат реестра
+	Insecure           bool   `yaml:"insecure,omitempty"`             // Как insecure registry Docker: без проверки сертификата, с откатом на HTTP
+
+	Repositories []string `yaml:"repositories,omitempty"` // Репозитории вместо _catalog: имена и шаблоны (myorg/*)
+
+	HubAPIURL     string   `yaml:"hub_api_url,omitempty"`    // Docker Hub API для списка репозиториев, по умолчанию https://hub.docker.com
+	HubNamespaces []string `yaml:"hub_namespaces,omitempty"` // Пространства имен Docker Hub для списка репозиториев
+
+	Proxy   string   `yaml:"proxy,omitempty"`    // http://, https://, socks5:// или socks5h://; по умолчанию HTTP(S)_PROXY
+	NoProxy []string `yaml:"no_proxy,omitempty"` // Хосты и подсети без прокси; по умолчанию NO_PROXY
+
+	MaxConcurrency  int           `yaml:"max_concurrency,omitempty"`    // Одновременных запросов к реестру, по умолчанию 8
+	MaxConnsPerHost int           `yaml:"max_conns_per_host,omitempty"` // Предел соединений с реестром, по умолчанию без ограничения
+	ConnectTimeout  time.Duration `yaml:"connect_timeout,omitempty"`    // Установка соединения и TLS handshake, по умолчанию 10s
+	RequestTimeout  time.Duration `yaml:"request_timeout,omitempty"`    // Ожидание ответа реестра (без скачивания тела), по умолчанию 30s
+	Retry           RetryPolicy   `yaml:"retry,omitempty"`
+}
+
+// RetryPolicy повторы запросов к реестру при сетевых ошибках, 429 и 5xx
+type RetryPolicy struct {
+	MaxRetries int           `yaml:"max_retries,omitempty"` // Повторов после первой попытки, по умолчанию 3; -1 отключает повторы
+	MinBackoff time.Duration `yaml:"min_backoff,omitempty"` // Задержка перед первым повтором, по умолчанию 500ms
+	MaxBackoff time.Duration `yaml:"max_backoff,omitempty"` // Предел задержки и ожидания Retry-After, по умолчанию 10s
 }
 
 type Config struct {
-	Inventory map[string]Registry `yaml:"inventory"`
+	Inventory          map[string]Registry `yaml:"inventory"`
+	InsecureRegistries []string            `yaml:"insecure_registries,omitempty"` // Подсети CIDR и host[:port], как insecure-registries в daemon.json
+	AuthFiles          []string            `yaml:"-"`                             // Загруженные файлы учетных данных Docker/Podman
+	Warnings           []string            `yaml:"-"`                             // Ошибки хелперов учетных данных, не мешающие запуску
 }
 
 // DockerConfig represents the structure of ~/.docker/config.json
 type DockerConfig struct {
-	Auths map[string]DockerAuth `json:"auths"`
+	Auths       map[string]DockerAuth `json:"auths"`
+	CredsStore  string                `json:"credsStore,omitempty"`
+	CredHelpers map[string]string     `json:"credHelpers,omitempty"`
 }
 
 type DockerAuth struct {
-	Auth string `json:"auth"`
+	Auth          string `json:"auth"`
+	IdentityToken string `json:"identitytoken,omitempty"`
+	RegistryToken string `json:"registrytoken,omitempty"`
 }
 
-func LoadConfig(filename string) (*Config, error) {
+// LoadConfig загружает inventory и дополняет его учетными данными Docker/Podman.
+// Файлы учетных данных применяются по убыванию приоритета: реестр получает учетные
+// данные из первого файла, где они есть; заданные в inventory не перезаписываются
+func LoadConfig(filename string, auth AuthOptions) (*Config, error) {
 	data, err := os.ReadFile(filename)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read config file: %w", err)
 	}
 
+	var document yaml.Node
+	if err := yaml.Unmarshal(data, &document); err != nil {
+		return nil, fmt.Errorf("failed to parse config file: %w", err)
+	}
+	if err := interpolateEnv(&document); err != nil {
+		return nil, fmt.Errorf("failed to interpolate config file: %w", err)
+	}
+
 	var config Config
-	if err := yaml.Unmarshal(data, &config); err != nil {
+	if err := document.Decode(&config); err != nil {
 		return nil, fmt.Errorf("failed to parse config file: %w", err)
 	}
 
@@ -46,72 +95,122 @@ func LoadConfig(filename string) (*Config, error) {
 		config.Inventory = make(map[string]Registry)
 	}
 
-	if dockerConfig, err := LoadDockerConfig(); err == nil {
-		mergeDockerConfig(&config, dockerConfig)
+	if err := config.resolveSecrets(); err != nil {
+		return nil, fmt.Errorf("failed to resolve secrets: %w", err)
 	}
 
-	return &config, nil
-}
-
-// LoadDockerConfig загружает ~/.docker/config.json
-func LoadDockerConfig() (*DockerConfig, error) {
-	homeDir, err := os.UserHomeDir()
-	if err != nil {
+	if err := config.validateRepositories(); err != nil {
 		return nil, err
 	}
 
-	dockerConfigPath := filepath.Join(homeDir, ".docker", "config.json")
-	data, err := os.ReadFile(dockerConfigPath)
+	authFiles, dockerConfigs, err := loadAuthFiles(auth)
 	if err != nil {
 		return nil, err
 	}
+	for _, dockerConfig := range dockerConfigs {
+		mergeDockerConfig(&config, dockerConfig)
+	}
+	config.AuthFiles = authFiles
 
-	var dockerConfig DockerConfig
-	if err := json.Unmarshal(data, &dockerConfig); err != nil {
-		return nil, err
+	if !auth.DisableDiscovery {
+		config.InsecureRegistries = append(config.InsecureRegistries, loadDaemonInsecureRegistries()...)
 	}
+	config.applyInsecureRegistries()
 
-	return &dockerConfig, nil
+	return &config, nil
 }
 
-// mergeDockerConfig объединяет Docker config с основной конфигурацией
+// mergeDockerConfig объединяет Docker config с основной конфигурацией. Учетные данные
+// добавляются к реестру inventory с тем же host, иначе создается новая запись по host
 func mergeDockerConfig(config *Config, dockerConfig *DockerConfig) {
-	for registryURL, auth := range dockerConfig.Auths {
-		host := strings.TrimPrefix(strings.TrimPrefix(registryURL, "https://"), "http://")
+	for _, registryURL := range dockerConfig.registryURLs() {
+		host := canonicalRegistryHost(registryURL)
+		creds, err := dockerConfig.resolveCredentials(registryURL)
+		if err != nil {
+			config.Warnings = append(config.Warnings, fmt.Sprintf("%s: %v", host, err))
+		}
 
-		if _, exists := config.Inventory[host]; !exists {
-			scheme := "http"
-			if !strings.Contains(host, "localhost") && !strings.Contains(host, "127.0.0.1") && !isPrivateIP(host) {
-				scheme = "https"
-			}
-			config.Inventory[host] = Registry{
-				URL:  fmt.Sprintf("%s://%s", scheme, host),
-				Auth: auth.Auth,
+		name, exists := config.findRegistryByHost(host)
+		if !exists {
+			name = host
+			if IsDockerHubHost(host) {
+				name = DockerHubName
 			}
+			// Схема не угадывается: сначала HTTPS, для loopback реестров с откатом на HTTP;
+			// остальные хосты настраиваются через insecure_registries и daemon.json
+			creds.URL = "https://" + host
+			creds.Insecure = isLoopbackHost(host)
+			config.Inventory[name] = creds
 		} else {
-			existing := config.Inventory[host]
-			if existing.Username == "" && existing.Password == "" && existing.Auth == "" {
-				existing.Auth = auth.Auth
-				config.Inventory[host] = existing
+			existing := config.Inventory[name]
+			if !existing.HasCredentials() {
+				existing.Username = creds.Username
+				existing.Password = creds.Password
+				existing.Auth = creds.Auth
+				existing.IdentityToken = creds.IdentityToken
+				existing.RegistryToken = creds.RegistryToken
+				config.Inventory[name] = existing
 			}
 		}
 	}
 }
 
-// isPrivateIP проверяет, является ли IP приватным
-func isPrivateIP(host string) bool {
-	privateRanges := []string{
-		"10.", "172.16.", "172.17.", "172.18.", "172.19.",
-		"172.20.", "172.21.", "172.22.", "172.23.", "172.24.",
-		"172.25.", "172.26.", "172.27.", "172.28.", "172.29.",
-		"172.30.", "172.31.", "192.168.",
-	}
-	for _, prefix := range privateRanges {
-		if strings.HasPrefix(host, prefix) {
-			return true
+// findRegistryByHost ищет реестр inventory по имени или host его URL
+func (c *Config) findRegistryByHost(host string) (string, bool) {
+	if _, exists := c.Inventory[host]; exists {
+		return host, true
+	}
+	if IsDockerHubHost(host) {
+		if _, exists := c.Inventory[DockerHubName]; exists {
+			return DockerHubName, true
+		}
+	}
+
+	for name, registry := range c.Inventory {
+		if canonicalRegistryHost(registry.URL) == host {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+// resolveCredentials возвращает учетные данные реестра из хелпера или поля auth
+func (dc *DockerConfig) resolveCredentials(registryURL string) (Registry, error) {
+	auth := dc.Auths[registryURL]
+	fallback := Registry{
+		Auth:          auth.Auth,
+		IdentityToken: auth.IdentityToken,
+		RegistryToken: auth.RegistryToken,
+	}
+
+	helper := dc.helperFor(registryURL)
+	if helper == "" {
+		return fallback, nil
+	}
+
+	// При ошибке хелпера остаются данные из auths, а ошибка возвращается для лога
+	username, secret, err := getHelperCredentials(helper, registryURL)
+	switch {
+	case err != nil:
+		return fallback, err
+	case username == identityTokenUsername && secret != "":
+		return Registry{IdentityToken: secret}, nil
+	case username != "" && secret != "":
+		return Registry{Username: username, Password: secret}, nil
+	}
+	return fallback, nil
+}
+
+// validateRepositories проверяет шаблоны в списках repositories
+func (c *Config) validateRepositories() error {
+	for name, registry := range c.Inventory {
+		for _, pattern := range registry.Repositories {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return fmt.Errorf("registry %q: invalid repository pattern %q: %w", name, pattern, err)
+			}
 		}
 	}
-	return false
+	return nil
 }
 
 func (c *Config) GetRegistry(name string) (Registry, bool) {
@@ -146,6 +245,12 @@ func (r *Registry) DecodeAuth() (username, password string, err error) {
 	return parts[0], parts[1], nil
 }
 
+// HasCredentials проверяет, задан ли хотя бы один способ авторизации
+func (r *Registry) HasCredentials() bool {
+	return r.Username != "" || r.Password != "" || r.Auth != "" ||
+		r.IdentityToken != "" || r.RegistryToken != ""
+}
+
 // GetCredentials возвращает username и password, приоритет у явно указанных
 func (r *Registry) GetCredentials() (username, password string, err error) {
 	if r.Username != "" && r.Password != "" {
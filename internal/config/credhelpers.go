@@ -0,0 +1,127 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os/exec"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Таймаут на вызов docker-credential-* хелпера
+const credentialHelperTimeout = 10 * time.Second
+
+// credentialHelperPrefix префикс исполняемых файлов хелперов
+const credentialHelperPrefix = "docker-credential-"
+
+// Хелперы возвращают это имя пользователя, если Secret является identity token
+const identityTokenUsername = "<token>"
+
+// Сообщение хелперов, когда для реестра нет сохраненных учетных данных
+const credentialsNotFoundMessage = "credentials not found in native keychain"
+
+// helperCredentials ответ хелпера на команду get
+type helperCredentials struct {
+	ServerURL string `json:"ServerURL"`
+	Username  string `json:"Username"`
+	Secret    string `json:"Secret"`
+}
+
+// helperFor возвращает имя хелпера для реестра: credHelpers имеет приоритет над credsStore.
+// Сначала ищется точный ключ, затем ключ того же host по правилам registryKeys
+func (dc *DockerConfig) helperFor(registryURL string) string {
+	if helper, exists := dc.CredHelpers[registryURL]; exists {
+		return helper
+	}
+
+	keys := registryKeys(slices.Collect(maps.Keys(dc.CredHelpers)))
+	if key, exists := keys[canonicalRegistryHost(registryURL)]; exists {
+		return dc.CredHelpers[key]
+	}
+	return dc.CredsStore
+}
+
+// registryURLs возвращает по одному ключу auths или credHelpers на каждый реестр.
+// Podman хранит ключи с пространством имен (quay.io/org1, quay.io/org2), а RegLite
+// работает с реестром целиком, поэтому из ключей одного host выбирается один:
+// ключ auths раньше credHelpers, ключ без пути раньше ключей с путем, затем по алфавиту
+func (dc *DockerConfig) registryURLs() []string {
+	best := registryKeys(slices.Collect(maps.Keys(dc.Auths)))
+	for host, key := range registryKeys(slices.Collect(maps.Keys(dc.CredHelpers))) {
+		if _, exists := best[host]; !exists {
+			best[host] = key
+		}
+	}
+
+	urls := make([]string, 0, len(best))
+	for _, host := range slices.Sorted(maps.Keys(best)) {
+		urls = append(urls, best[host])
+	}
+	return urls
+}
+
+// registryKeys выбирает для каждого host один ключ: без пути, иначе первый по алфавиту
+func registryKeys(keys []string) map[string]string {
+	slices.Sort(keys)
+
+	best := make(map[string]string, len(keys))
+	for _, key := range keys {
+		host := canonicalRegistryHost(key)
+		current, exists := best[host]
+		if !exists || (isHostOnlyKey(key) && !isHostOnlyKey(current)) {
+			best[host] = key
+		}
+	}
+	return best
+}
+
+// isHostOnlyKey проверяет, что ключ config.json указывает на реестр, а не на пространство имен в нем
+func isHostOnlyKey(key string) bool {
+	key = strings.TrimPrefix(strings.TrimPrefix(key, "https://"), "http://")
+	return !strings.Contains(strings.TrimSuffix(key, "/"), "/")
+}
+
+// getHelperCredentials получает учетные данные через протокол docker-credential-<name> get
+func getHelperCredentials(helper, serverURL string) (username, secret string, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), credentialHelperTimeout)
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, credentialHelperPrefix+helper, "get")
+	cmd.Stdin = strings.NewReader(serverURL)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		message := strings.TrimSpace(stdout.String())
+		if message == "" {
+			message = strings.TrimSpace(stderr.String())
+		}
+		// Отсутствие учетных данных не ошибка: используется auth из config.json
+		if message == credentialsNotFoundMessage {
+			return "", "", nil
+		}
+		if message != "" {
+			return "", "", fmt.Errorf("credential helper %s: %s: %w", helper, message, err)
+		}
+		return "", "", fmt.Errorf("credential helper %s: %w", helper, err)
+	}
+
+	var creds helperCredentials
+	if err := json.Unmarshal(stdout.Bytes(), &creds); err != nil {
+		return "", "", fmt.Errorf("credential helper %s returned invalid response: %w", helper, err)
+	}
+
+	return creds.Username, creds.Secret, nil
+}
+
+// normalizeRegistryHost убирает схему и путь из адреса реестра
+func normalizeRegistryHost(registryURL string) string {
+	host := strings.TrimPrefix(strings.TrimPrefix(registryURL, "https://"), "http://")
+	host, _, _ = strings.Cut(host, "/")
+	return host
+}
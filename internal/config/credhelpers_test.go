@@ -0,0 +1,144 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// fakeHelperScript отвечает как docker-credential-* на команду get по адресу из stdin
+const fakeHelperScript = `#!/bin/sh
+[ "$1" = "get" ] || exit 2
+server=$(cat)
+case "$server" in
+  registry.example.com) echo '{"ServerURL":"registry.example.com","Username":"helper-user","Secret":"helper-pass"}' ;;
+  token.example.com) echo '{"ServerURL":"token.example.com","Username":"<token>","Secret":"refresh-token"}' ;;
+  missing.example.com) echo 'credentials not found in native keychain'; exit 1 ;;
+  *) echo 'keychain is locked' >&2; exit 1 ;;
+esac
+`
+
+// installFakeHelper кладет docker-credential-fake в PATH теста
+func installFakeHelper(t *testing.T) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("shell credential helper is not supported on windows")
+	}
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, credentialHelperPrefix+"fake"), []byte(fakeHelperScript), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+}
+
+func TestGetHelperCredentials(t *testing.T) {
+	installFakeHelper(t)
+
+	username, secret, err := getHelperCredentials("fake", "registry.example.com")
+	if err != nil {
+		t.Fatalf("getHelperCredentials: %v", err)
+	}
+	if username != "helper-user" || secret != "helper-pass" {
+		t.Errorf("credentials = %q/%q, want helper-user/helper-pass", username, secret)
+	}
+
+	username, secret, err = getHelperCredentials("fake", "missing.example.com")
+	if err != nil || username != "" || secret != "" {
+		t.Errorf("not found: got %q/%q, %v; want empty credentials without error", username, secret, err)
+	}
+
+	_, _, err = getHelperCredentials("fake", "broken.example.com")
+	if err == nil || !strings.Contains(err.Error(), "keychain is locked") {
+		t.Errorf("error = %v, want helper stderr in error", err)
+	}
+
+	if _, _, err := getHelperCredentials("absent", "registry.example.com"); err == nil {
+		t.Error("missing helper binary: expected error")
+	}
+}
+
+func TestLoadConfigCredentialHelpers(t *testing.T) {
+	installFakeHelper(t)
+
+	dockerConfig := writeConfig(t, "config.json", `{
+		"auths": {
+			"broken.example.com": {"auth": "dXNlcjpmYWxsYmFjaw=="}
+		},
+		"credHelpers": {
+			"registry.example.com": "fake",
+			"token.example.com": "fake",
+			"missing.example.com": "fake",
+			"broken.example.com": "fake"
+		}
+	}`)
+
+	path := writeConfig(t, "inventory.yaml", "inventory: {}\n")
+	cfg, err := LoadConfig(path, AuthOptions{Files: []string{dockerConfig}, DisableDiscovery: true})
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+
+	reg := cfg.Inventory["registry.example.com"]
+	if reg.Username != "helper-user" || reg.Password != "helper-pass" {
+		t.Errorf("registry.example.com credentials = %q/%q", reg.Username, reg.Password)
+	}
+
+	// Имя пользователя <token> означает identity token
+	reg = cfg.Inventory["token.example.com"]
+	if reg.IdentityToken != "refresh-token" || reg.Username != "" {
+		t.Errorf("token.example.com = %+v, want identity token", reg)
+	}
+
+	if reg := cfg.Inventory["missing.example.com"]; reg.HasCredentials() {
+		t.Errorf("missing.example.com has credentials: %+v", reg)
+	}
+
+	// Ошибка хелпера попадает в Warnings, а реестр получает auth из config.json
+	reg = cfg.Inventory["broken.example.com"]
+	if username, password, err := reg.GetCredentials(); err != nil || username != "user" || password != "fallback" {
+		t.Errorf("broken.example.com credentials = %q/%q, %v; want auth fallback", username, password, err)
+	}
+	if len(cfg.Warnings) != 1 || !strings.Contains(cfg.Warnings[0], "broken.example.com") ||
+		!strings.Contains(cfg.Warnings[0], "keychain is locked") {
+		t.Errorf("Warnings = %q, want one helper error for broken.example.com", cfg.Warnings)
+	}
+}
+
+func TestRegistryURLsNamespacedKeys(t *testing.T) {
+	dc := &DockerConfig{
+		Auths: map[string]DockerAuth{
+			"quay.io/org2":               {Auth: "b3JnMjpwYXNz"},
+			"quay.io/org1":               {Auth: "b3JnMTpwYXNz"},
+			"https://ghcr.io/team/app":   {Auth: "YXBwOnBhc3M="},
+			"ghcr.io":                    {Auth: "Z2hjcjpwYXNz"},
+			"https://index.docker.io/v1": {Auth: "aHViOnBhc3M="},
+		},
+		CredHelpers: map[string]string{
+			"quay.io":                   "fake",
+			"https://gcr.io/project":    "gcloud",
+			"gcr.io":                    "gcr",
+			"registry.example.com/team": "fake",
+		},
+	}
+
+	// По одному ключу на реестр в порядке host: registry-1.docker.io раньше registry.example.com
+	want := []string{"gcr.io", "ghcr.io", "quay.io/org1", "https://index.docker.io/v1", "registry.example.com/team"}
+	for i := 0; i < 20; i++ {
+		if got := dc.registryURLs(); strings.Join(got, " ") != strings.Join(want, " ") {
+			t.Fatalf("registryURLs() = %v, want %v", got, want)
+		}
+	}
+
+	if helper := dc.helperFor("gcr.io"); helper != "gcr" {
+		t.Errorf("helperFor(gcr.io) = %q, want gcr (host-only key)", helper)
+	}
+	if helper := dc.helperFor("https://gcr.io/project"); helper != "gcloud" {
+		t.Errorf("helperFor(gcr.io/project) = %q, want exact key", helper)
+	}
+	if helper := dc.helperFor("quay.io/org1"); helper != "fake" {
+		t.Errorf("helperFor(quay.io/org1) = %q, want fake", helper)
+	}
+}
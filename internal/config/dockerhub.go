@@ -0,0 +1,46 @@
+package config
+
+import (
+	"net/url"
+	"strings"
+)
+
+// Docker Hub: v2 endpoint реестра и имя записи inventory для найденных учетных данных
+const (
+	DockerHubHost     = "registry-1.docker.io"
+	DockerHubName     = "docker.io"
+	DefaultHubAPIURL  = "https://hub.docker.com"
+	dockerHubAuthHost = "index.docker.io"
+)
+
+// dockerHubAliases адреса, под которыми Docker Hub встречается в config.json и inventory
+var dockerHubAliases = map[string]bool{
+	DockerHubHost:             true,
+	DockerHubName:             true,
+	dockerHubAuthHost:         true,
+	"registry.hub.docker.com": true,
+}
+
+// IsDockerHubHost проверяет, является ли host одним из адресов Docker Hub
+func IsDockerHubHost(host string) bool {
+	return dockerHubAliases[strings.ToLower(host)]
+}
+
+// canonicalRegistryHost приводит ключ config.json к host реестра; все адреса Docker Hub
+// (включая https://index.docker.io/v1/) сводятся к registry-1.docker.io
+func canonicalRegistryHost(registryURL string) string {
+	host := normalizeRegistryHost(registryURL)
+	if IsDockerHubHost(host) {
+		return DockerHubHost
+	}
+	return host
+}
+
+// IsDockerHub проверяет, является ли реестр Docker Hub: по адресу или по заданному hub_api_url
+func (r *Registry) IsDockerHub() bool {
+	if r.HubAPIURL != "" {
+		return true
+	}
+	parsed, err := url.Parse(r.URL)
+	return err == nil && IsDockerHubHost(parsed.Host)
+}
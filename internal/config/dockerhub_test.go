@@ -0,0 +1,69 @@
+package config
+
+import (
+	"testing"
+)
+
+func TestCanonicalRegistryHost(t *testing.T) {
+	tests := map[string]string{
+		"https://index.docker.io/v1/":     DockerHubHost,
+		"index.docker.io":                 DockerHubHost,
+		"docker.io":                       DockerHubHost,
+		"https://registry-1.docker.io":    DockerHubHost,
+		"registry.hub.docker.com":         DockerHubHost,
+		"https://registry.example.com/v2": "registry.example.com",
+		"localhost:5000":                  "localhost:5000",
+	}
+
+	for registryURL, want := range tests {
+		if got := canonicalRegistryHost(registryURL); got != want {
+			t.Errorf("canonicalRegistryHost(%q) = %q, want %q", registryURL, got, want)
+		}
+	}
+}
+
+func TestMergeDockerHubCredentials(t *testing.T) {
+	// docker login сохраняет Docker Hub под https://index.docker.io/v1/
+	dockerConfig := writeConfig(t, "config.json", `{"auths": {"https://index.docker.io/v1/": {"auth": "aHVidXNlcjpodWJwYXNz"}}}`)
+
+	cfg, err := LoadConfig(writeConfig(t, "inventory.yaml", "inventory: {}\n"), AuthOptions{Files: []string{dockerConfig}, DisableDiscovery: true})
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+
+	reg, ok := cfg.GetRegistry(DockerHubName)
+	if !ok {
+		t.Fatalf("registry %s not found in %v", DockerHubName, cfg.GetRegistryNames())
+	}
+	if reg.URL != "https://"+DockerHubHost {
+		t.Errorf("URL = %q, want https://%s", reg.URL, DockerHubHost)
+	}
+	if !reg.IsDockerHub() {
+		t.Error("IsDockerHub() = false")
+	}
+	if username, password, err := reg.GetCredentials(); err != nil || username != "hubuser" || password != "hubpass" {
+		t.Errorf("credentials = %q/%q, %v", username, password, err)
+	}
+}
+
+func TestMergeDockerHubCredentialsIntoInventory(t *testing.T) {
+	dockerConfig := writeConfig(t, "config.json", `{"auths": {"https://index.docker.io/v1/": {"auth": "aHVidXNlcjpodWJwYXNz"}}}`)
+	path := writeConfig(t, "inventory.yaml", `
+inventory:
+  hub:
+    url: https://registry-1.docker.io
+`)
+
+	cfg, err := LoadConfig(path, AuthOptions{Files: []string{dockerConfig}, DisableDiscovery: true})
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+
+	if len(cfg.Inventory) != 1 {
+		t.Fatalf("inventory = %v, want only hub", cfg.GetRegistryNames())
+	}
+	reg, _ := cfg.GetRegistry("hub")
+	if username, _, err := reg.GetCredentials(); err != nil || username != "hubuser" {
+		t.Errorf("hub username = %q, %v", username, err)
+	}
+}
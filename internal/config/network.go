@@ -0,0 +1,104 @@
+package config
+
+import (
+	"encoding/json"
+	"net"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Расположение daemon.json Docker: системный и rootless
+var daemonConfigPaths = func() []string {
+	paths := []string{"/etc/docker/daemon.json"}
+	if configHome := os.Getenv("XDG_CONFIG_HOME"); configHome != "" {
+		paths = append(paths, filepath.Join(configHome, "docker", "daemon.json"))
+	} else if homeDir, err := os.UserHomeDir(); err == nil {
+		paths = append(paths, filepath.Join(homeDir, ".config", "docker", "daemon.json"))
+	}
+	return paths
+}
+
+// daemonConfig поля daemon.json, которые использует RegLite
+type daemonConfig struct {
+	InsecureRegistries []string `json:"insecure-registries"`
+}
+
+// splitHost отделяет порт от host, в том числе для IPv6 в квадратных скобках
+func splitHost(host string) string {
+	if hostname, _, err := net.SplitHostPort(host); err == nil {
+		return hostname
+	}
+	return strings.Trim(host, "[]")
+}
+
+// isLoopbackHost проверяет, что host (с портом или без) это localhost или loopback адрес.
+// Как и в Docker, только такие реестры считаются insecure без явной настройки:
+// для частных сетей это отключило бы проверку сертификата и отправку пароля по HTTPS
+func isLoopbackHost(host string) bool {
+	hostname := splitHost(host)
+	if strings.EqualFold(hostname, "localhost") {
+		return true
+	}
+	addr, err := netip.ParseAddr(hostname)
+	return err == nil && addr.Unmap().IsLoopback()
+}
+
+// matchesInsecureRegistry проверяет host по списку insecure registries: подсети CIDR
+// применяются к IP адресам, остальные записи сравниваются как host[:port]
+func matchesInsecureRegistry(host string, entries []string) bool {
+	addr, addrErr := netip.ParseAddr(splitHost(host))
+
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if prefix, err := netip.ParsePrefix(entry); err == nil {
+			if addrErr == nil && prefix.Contains(addr.Unmap()) {
+				return true
+			}
+			continue
+		}
+
+		if strings.EqualFold(entry, host) {
+			return true
+		}
+		// Запись без порта подходит для любого порта
+		entryHost := splitHost(entry)
+		if entryHost == strings.Trim(entry, "[]") && strings.EqualFold(entryHost, splitHost(host)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// loadDaemonInsecureRegistries читает insecure-registries из daemon.json Docker
+func loadDaemonInsecureRegistries() []string {
+	var entries []string
+	for _, path := range daemonConfigPaths() {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		var daemon daemonConfig
+		if err := json.Unmarshal(data, &daemon); err != nil {
+			continue
+		}
+		entries = append(entries, daemon.InsecureRegistries...)
+	}
+	return entries
+}
+
+// applyInsecureRegistries помечает небезопасными реестры из insecure_registries
+func (c *Config) applyInsecureRegistries() {
+	if len(c.InsecureRegistries) == 0 {
+		return
+	}
+
+	for name, registry := range c.Inventory {
+		if matchesInsecureRegistry(normalizeRegistryHost(registry.URL), c.InsecureRegistries) {
+			registry.Insecure = true
+			c.Inventory[name] = registry
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package config
+
+import "testing"
+
+func TestIsLoopbackHost(t *testing.T) {
+	tests := map[string]bool{
+		"localhost":            true,
+		"LOCALHOST:5000":       true,
+		"127.0.0.1":            true,
+		"127.1.2.3:5000":       true,
+		"[::1]:5000":           true,
+		"::1":                  true,
+		"[::ffff:127.0.0.1]":   true,
+		"10.0.0.5:5000":        false,
+		"192.168.1.10":         false,
+		"169.254.1.1":          false,
+		"[fd00::1]:5000":       false,
+		"10.example.com":       false,
+		"registry.example.com": false,
+	}
+
+	for host, want := range tests {
+		if got := isLoopbackHost(host); got != want {
+			t.Errorf("isLoopbackHost(%q) = %v, want %v", host, got, want)
+		}
+	}
+}
+
+func TestMatchesInsecureRegistry(t *testing.T) {
+	entries := []string{"10.0.0.0/8", "registry.internal", "mirror.internal:5000", "fd00::/8"}
+
+	tests := map[string]bool{
+		"10.1.2.3:5000":          true,
+		"192.168.1.1":            false,
+		"registry.internal:443":  true,
+		"mirror.internal:5000":   true,
+		"mirror.internal:5001":   false,
+		"[fd00::1]:5000":         true,
+		"10.example.com":         false,
+		"other.registry.example": false,
+	}
+
+	for host, want := range tests {
+		if got := matchesInsecureRegistry(host, entries); got != want {
+			t.Errorf("matchesInsecureRegistry(%q) = %v, want %v", host, got, want)
+		}
+	}
+}
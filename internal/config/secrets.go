@@ -0,0 +1,121 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// envReference ссылка ${VAR} на переменную окружения; $${ экранирует литерал ${
+var envReference = regexp.MustCompile(`\$\$\{|\$\{([^}]*)\}`)
+
+// envName допустимое имя переменной окружения
+var envName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// interpolateEnv подставляет переменные окружения во все скалярные значения YAML документа.
+// Подстановка идет после разбора YAML, поэтому спецсимволы в значениях не ломают разметку
+func interpolateEnv(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		value, err := expandEnv(node.Value)
+		if err != nil {
+			return fmt.Errorf("line %d: %w", node.Line, err)
+		}
+		// Тип обычного скаляра определяется по значению, а для ${VAR} yaml уже выбрал !!str;
+		// сбрасываем тег, чтобы после подстановки числа и bool разбирались как обычно
+		if value != node.Value && node.Style&(yaml.TaggedStyle|yaml.DoubleQuotedStyle|yaml.SingleQuotedStyle|yaml.LiteralStyle|yaml.FoldedStyle) == 0 {
+			node.Tag = ""
+		}
+		node.Value = value
+		return nil
+	}
+
+	for _, child := range node.Content {
+		if err := interpolateEnv(child); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// expandEnv заменяет ${VAR} значениями переменных окружения
+func expandEnv(value string) (string, error) {
+	var expandErr error
+
+	result := envReference.ReplaceAllStringFunc(value, func(match string) string {
+		if match == "$${" {
+			return "${"
+		}
+
+		name := match[2 : len(match)-1]
+		if !envName.MatchString(name) {
+			if expandErr == nil {
+				expandErr = fmt.Errorf("invalid environment variable reference %q", match)
+			}
+			return match
+		}
+
+		envValue, exists := os.LookupEnv(name)
+		if !exists && expandErr == nil {
+			expandErr = fmt.Errorf("environment variable %s is not set", name)
+		}
+		return envValue
+	})
+
+	return result, expandErr
+}
+
+// resolveSecrets подставляет пароли из password_env и password_file
+func (c *Config) resolveSecrets() error {
+	names := c.GetRegistryNames()
+	sort.Strings(names)
+
+	for _, name := range names {
+		registry := c.Inventory[name]
+		if err := registry.resolvePassword(); err != nil {
+			return fmt.Errorf("registry %q: %w", name, err)
+		}
+		c.Inventory[name] = registry
+	}
+
+	return nil
+}
+
+// resolvePassword читает пароль из переменной окружения или файла
+func (r *Registry) resolvePassword() error {
+	sources := 0
+	for _, value := range []string{r.Password, r.PasswordEnv, r.PasswordFile} {
+		if value != "" {
+			sources++
+		}
+	}
+	if sources > 1 {
+		return fmt.Errorf("only one of password, password_env and password_file can be set")
+	}
+
+	switch {
+	case r.PasswordEnv != "":
+		password, exists := os.LookupEnv(r.PasswordEnv)
+		if !exists || password == "" {
+			return fmt.Errorf("password_env: environment variable %s is not set or empty", r.PasswordEnv)
+		}
+		r.Password = password
+
+	case r.PasswordFile != "":
+		data, err := os.ReadFile(r.PasswordFile)
+		if err != nil {
+			return fmt.Errorf("password_file: %w", err)
+		}
+		password := strings.TrimRight(string(data), "\r\n")
+		if password == "" {
+			return fmt.Errorf("password_file: %s is empty", r.PasswordFile)
+		}
+		r.Password = password
+	}
+
+	return nil
+}
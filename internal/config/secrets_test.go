@@ -0,0 +1,110 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeConfig записывает файл конфигурации name во временный каталог теста
+func writeConfig(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadConfigInterpolatesTypedFields(t *testing.T) {
+	t.Setenv("REGLITE_TEST_URL", "https://registry.example.com")
+	t.Setenv("REGLITE_TEST_CONCURRENCY", "4")
+	t.Setenv("REGLITE_TEST_INSECURE", "true")
+	t.Setenv("REGLITE_TEST_TIMEOUT", "15s")
+	t.Setenv("REGLITE_TEST_PASSWORD", "123456")
+
+	path := writeConfig(t, "inventory.yaml", `
+inventory:
+  example:
+    url: ${REGLITE_TEST_URL}
+    username: user
+    password: ${REGLITE_TEST_PASSWORD}
+    max_concurrency: ${REGLITE_TEST_CONCURRENCY}
+    insecure: ${REGLITE_TEST_INSECURE}
+    connect_timeout: ${REGLITE_TEST_TIMEOUT}
+`)
+
+	cfg, err := LoadConfig(path, AuthOptions{DisableDiscovery: true})
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+
+	reg, ok := cfg.GetRegistry("example")
+	if !ok {
+		t.Fatal("registry example not found")
+	}
+	if reg.URL != "https://registry.example.com" {
+		t.Errorf("URL = %q", reg.URL)
+	}
+	if reg.MaxConcurrency != 4 {
+		t.Errorf("MaxConcurrency = %d, want 4", reg.MaxConcurrency)
+	}
+	if !reg.Insecure {
+		t.Error("Insecure = false, want true")
+	}
+	if reg.ConnectTimeout != 15*time.Second {
+		t.Errorf("ConnectTimeout = %v, want 15s", reg.ConnectTimeout)
+	}
+	// Числовой пароль остается строкой
+	if reg.Password != "123456" {
+		t.Errorf("Password = %q, want 123456", reg.Password)
+	}
+}
+
+func TestLoadConfigKeepsQuotedValuesAsStrings(t *testing.T) {
+	t.Setenv("REGLITE_TEST_CONCURRENCY", "4")
+
+	path := writeConfig(t, "inventory.yaml", `
+inventory:
+  example:
+    url: https://registry.example.com
+    max_concurrency: "${REGLITE_TEST_CONCURRENCY}"
+`)
+
+	// Явно заданная строка не становится числом
+	if _, err := LoadConfig(path, AuthOptions{DisableDiscovery: true}); err == nil {
+		t.Fatal("expected error for quoted max_concurrency")
+	}
+}
+
+func TestLoadConfigEscapedReference(t *testing.T) {
+	path := writeConfig(t, "inventory.yaml", `
+inventory:
+  example:
+    url: https://registry.example.com
+    username: user
+    password: p$${literal}
+`)
+
+	cfg, err := LoadConfig(path, AuthOptions{DisableDiscovery: true})
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	reg, _ := cfg.GetRegistry("example")
+	if reg.Password != "p${literal}" {
+		t.Errorf("Password = %q, want p${literal}", reg.Password)
+	}
+}
+
+func TestLoadConfigMissingVariable(t *testing.T) {
+	path := writeConfig(t, "inventory.yaml", `
+inventory:
+  example:
+    url: ${REGLITE_TEST_UNSET_VARIABLE}
+`)
+
+	if _, err := LoadConfig(path, AuthOptions{DisableDiscovery: true}); err == nil {
+		t.Fatal("expected error for unset variable")
+	}
+}
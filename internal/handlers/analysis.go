@@ -0,0 +1,134 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/reglite/reglite/internal/config"
+	"github.com/reglite/reglite/internal/registry"
+)
+
+// Количество общих слоев и репозиториев в отчете по умолчанию
+const defaultAnalysisTopN = 20
+
+// Статусы задачи анализа хранилища
+const (
+	analysisRunning   = "running"
+	analysisCompleted = "completed"
+	analysisFailed    = "failed"
+)
+
+// StorageAnalysis задача анализа хранилища реестра
+type StorageAnalysis struct {
+	Registry     string                  `json:"registry"`
+	Status       string                  `json:"status"`
+	StartedAt    time.Time               `json:"startedAt"`
+	FinishedAt   *time.Time              `json:"finishedAt,omitempty"`
+	Processed    int                     `json:"processed"` // Обработано репозиториев
+	Total        int                     `json:"total"`     // Всего репозиториев в каталоге
+	TopN         int                     `json:"topN"`
+	ErrorMessage string                  `json:"errorMessage,omitempty"`
+	Report       *registry.StorageReport `json:"report,omitempty"`
+}
+
+// runStorageAnalysis выполняет анализ и обновляет состояние задачи
+func (h *Handler) runStorageAnalysis(analysis *StorageAnalysis, reg config.Registry) {
+	client := h.clients.Get(analysis.Registry, reg)
+	report, err := client.AnalyzeStorage(context.Background(), analysis.TopN, func(done, total int) {
+		h.analysisMutex.Lock()
+		analysis.Processed = done
+		analysis.Total = total
+		h.analysisMutex.Unlock()
+	})
+
+	finishedAt := time.Now()
+
+	h.analysisMutex.Lock()
+	defer h.analysisMutex.Unlock()
+
+	analysis.FinishedAt = &finishedAt
+	if err != nil {
+		analysis.Status = analysisFailed
+		analysis.ErrorMessage = err.Error()
+		return
+	}
+
+	analysis.Status = analysisCompleted
+	analysis.Report = report
+}
+
+// StartStorageAnalysis запускает анализ хранилища реестра в фоне
+func (h *Handler) StartStorageAnalysis(c *gin.Context) {
+	registryName := extractRegistryParam(c)
+
+	if registryName == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Registry parameter is required"})
+		return
+	}
+
+	reg, exists := h.config.GetRegistry(registryName)
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Registry not found"})
+		return
+	}
+
+	topN := defaultAnalysisTopN
+	if value := c.Query("top"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Parameter top must be a positive integer"})
+			return
+		}
+		topN = parsed
+	}
+
+	h.analysisMutex.Lock()
+	defer h.analysisMutex.Unlock()
+
+	// Повторный запуск во время выполнения возвращает текущую задачу
+	if current, exists := h.storageAnalyses[registryName]; exists && current.Status == analysisRunning {
+		c.JSON(http.StatusAccepted, *current)
+		return
+	}
+
+	analysis := &StorageAnalysis{
+		Registry:  registryName,
+		Status:    analysisRunning,
+		StartedAt: time.Now(),
+		TopN:      topN,
+	}
+	h.storageAnalyses[registryName] = analysis
+
+	go h.runStorageAnalysis(analysis, reg)
+
+	c.JSON(http.StatusAccepted, *analysis)
+}
+
+// GetStorageAnalysis возвращает состояние и результат последнего анализа хранилища
+func (h *Handler) GetStorageAnalysis(c *gin.Context) {
+	registryName := extractRegistryParam(c)
+
+	if registryName == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Registry parameter is required"})
+		return
+	}
+
+	if _, exists := h.config.GetRegistry(registryName); !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Registry not found"})
+		return
+	}
+
+	h.analysisMutex.RLock()
+	defer h.analysisMutex.RUnlock()
+
+	analysis, exists := h.storageAnalyses[registryName]
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Storage analysis has not been started"})
+		return
+	}
+
+	c.JSON(http.StatusOK, *analysis)
+}
@@ -1,7 +1,14 @@
 package handlers
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
 	"net/http"
+	"path"
+	"strconv"
 	"sync"
 	"time"
 
@@ -10,6 +17,9 @@ import (
 	"github.com/reglite/reglite/internal/registry"
 )
 
+// Максимальное время проверки одного реестра, чтобы валидация всегда завершалась
+const validationTimeout = time.Minute
+
 type RegistryStatus struct {
 	Name         string    `json:"name"`
 	URL          string    `json:"url"`
@@ -17,6 +27,8 @@ type RegistryStatus struct {
 	LastChecked  time.Time `json:"lastChecked"`
 	ResponseTime int64     `json:"responseTime"`
 	ErrorMessage string    `json:"errorMessage,omitempty"`
+
+	RateLimit *registry.RateLimit `json:"rateLimit,omitempty"` // Последний лимит запросов, сообщенный реестром
 }
 
 type RegistriesResponse struct {
@@ -26,14 +38,19 @@ type RegistriesResponse struct {
 
 type Handler struct {
 	config           *config.Config
+	clients          *registry.Pool
 	registryStatuses map[string]*RegistryStatus
 	statusMutex      sync.RWMutex
+	storageAnalyses  map[string]*StorageAnalysis // Последний анализ хранилища по реестрам
+	analysisMutex    sync.RWMutex
 }
 
 func NewHandler(cfg *config.Config) *Handler {
 	return &Handler{
 		config:           cfg,
+		clients:          registry.NewPool(),
 		registryStatuses: make(map[string]*RegistryStatus),
+		storageAnalyses:  make(map[string]*StorageAnalysis),
 	}
 }
 
@@ -47,8 +64,11 @@ func (h *Handler) validateRegistry(name string, reg config.Registry) *RegistrySt
 		LastChecked: startTime,
 	}
 
-	client := registry.NewClient(reg)
-	_, err := client.GetCatalog()
+	ctx, cancel := context.WithTimeout(context.Background(), validationTimeout)
+	defer cancel()
+
+	client := h.clients.Get(name, reg)
+	err := client.Ping(ctx)
 
 	responseTime := time.Since(startTime).Milliseconds()
 	status.ResponseTime = responseTime
@@ -60,6 +80,7 @@ func (h *Handler) validateRegistry(name string, reg config.Registry) *RegistrySt
 		status.Status = "online"
 		status.ErrorMessage = ""
 	}
+	status.RateLimit = client.RateLimit()
 
 	return status
 }
@@ -94,7 +115,9 @@ func (h *Handler) GetRegistriesWithStatus(c *gin.Context) {
 	// Собираем статусы всех реестров
 	for name, reg := range h.config.Inventory {
 		if status, exists := h.registryStatuses[name]; exists {
-			registries = append(registries, *status)
+			current := *status
+			current.RateLimit = h.clients.Get(name, reg).RateLimit()
+			registries = append(registries, current)
 			if status.LastChecked.Before(lastUpdate) {
 				lastUpdate = status.LastChecked
 			}
@@ -105,6 +128,7 @@ func (h *Handler) GetRegistriesWithStatus(c *gin.Context) {
 				URL:         reg.URL,
 				Status:      "checking",
 				LastChecked: time.Now(),
+				RateLimit:   h.clients.Get(name, reg).RateLimit(),
 			})
 		}
 	}
@@ -151,6 +175,29 @@ func extractRegistryParam(c *gin.Context) string {
 	return registry
 }
 
+// disableWriteDeadline снимает WriteTimeout сервера для ответов, которые читают слои
+// целиком и не укладываются в общий таймаут; запрос по-прежнему отменяется через context
+func disableWriteDeadline(c *gin.Context) {
+	_ = http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{})
+}
+
+// extractPageParams извлекает параметры пагинации: размер страницы n и курсор last
+func extractPageParams(c *gin.Context) (n int, last string, paged bool, ok bool) {
+	last = c.Query("last")
+	size := c.Query("n")
+	if size == "" {
+		return 0, last, last != "", true
+	}
+
+	n, err := strconv.Atoi(size)
+	if err != nil || n <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Parameter n must be a positive integer"})
+		return 0, "", false, false
+	}
+
+	return n, last, true, true
+}
+
 func (h *Handler) GetRepositories(c *gin.Context) {
 	registryName := extractRegistryParam(c)
 
@@ -165,8 +212,19 @@ func (h *Handler) GetRepositories(c *gin.Context) {
 		return
 	}
 
-	client := registry.NewClient(reg)
-	catalog, err := client.GetCatalog()
+	n, last, paged, ok := extractPageParams(c)
+	if !ok {
+		return
+	}
+
+	client := h.clients.Get(registryName, reg)
+	var catalog *registry.CatalogResponse
+	var err error
+	if paged {
+		catalog, err = client.GetCatalogPage(c.Request.Context(), n, last)
+	} else {
+		catalog, err = client.GetCatalog(c.Request.Context())
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -195,8 +253,8 @@ func (h *Handler) GetRepositoryInfo(c *gin.Context) {
 		return
 	}
 
-	client := registry.NewClient(reg)
-	info, err := client.GetRepositoryInfo(repository)
+	client := h.clients.Get(registryName, reg)
+	info, err := client.GetRepositoryInfo(c.Request.Context(), repository)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -225,8 +283,19 @@ func (h *Handler) GetTags(c *gin.Context) {
 		return
 	}
 
-	client := registry.NewClient(reg)
-	tags, err := client.GetTags(repository)
+	n, last, paged, ok := extractPageParams(c)
+	if !ok {
+		return
+	}
+
+	client := h.clients.Get(registryName, reg)
+	var tags *registry.TagsResponse
+	var err error
+	if paged {
+		tags, err = client.GetTagsPage(c.Request.Context(), repository, n, last)
+	} else {
+		tags, err = client.GetTags(c.Request.Context(), repository)
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -261,8 +330,14 @@ func (h *Handler) GetManifest(c *gin.Context) {
 		return
 	}
 
-	client := registry.NewClient(reg)
-	manifest, err := client.GetManifest(repository, tag)
+	client := h.clients.Get(registryName, reg)
+	var manifest *registry.ManifestResponse
+	var err error
+	if platform := c.Query("platform"); platform != "" {
+		manifest, err = client.GetPlatformManifest(c.Request.Context(), repository, tag, platform)
+	} else {
+		manifest, err = client.GetManifest(c.Request.Context(), repository, tag)
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -297,8 +372,8 @@ func (h *Handler) DeleteTag(c *gin.Context) {
 		return
 	}
 
-	client := registry.NewClient(reg)
-	err := client.DeleteManifest(repository, digest)
+	client := h.clients.Get(registryName, reg)
+	err := client.DeleteManifest(c.Request.Context(), repository, digest)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -307,6 +382,311 @@ func (h *Handler) DeleteTag(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "Tag deleted successfully"})
 }
 
+func (h *Handler) GetReferrers(c *gin.Context) {
+	registryName := extractRegistryParam(c)
+	repository := extractRepositoryParam(c)
+	digest := c.Query("digest")
+
+	if registryName == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Registry parameter is required"})
+		return
+	}
+
+	if repository == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Repository parameter is required"})
+		return
+	}
+
+	if digest == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Digest parameter is required"})
+		return
+	}
+
+	reg, exists := h.config.GetRegistry(registryName)
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Registry not found"})
+		return
+	}
+
+	client := h.clients.Get(registryName, reg)
+	referrers, err := client.GetReferrers(c.Request.Context(), repository, digest)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, referrers)
+}
+
+func (h *Handler) GetImageConfig(c *gin.Context) {
+	registryName := extractRegistryParam(c)
+	repository := extractRepositoryParam(c)
+	tag := c.Query("tag")
+
+	if registryName == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Registry parameter is required"})
+		return
+	}
+
+	if repository == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Repository parameter is required"})
+		return
+	}
+
+	if tag == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Tag parameter is required"})
+		return
+	}
+
+	reg, exists := h.config.GetRegistry(registryName)
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Registry not found"})
+		return
+	}
+
+	client := h.clients.Get(registryName, reg)
+	imageConfig, err := client.GetImageConfig(c.Request.Context(), repository, tag, c.Query("platform"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, imageConfig)
+}
+
+func (h *Handler) GetImageHistory(c *gin.Context) {
+	registryName := extractRegistryParam(c)
+	repository := extractRepositoryParam(c)
+	tag := c.Query("tag")
+
+	if registryName == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Registry parameter is required"})
+		return
+	}
+
+	if repository == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Repository parameter is required"})
+		return
+	}
+
+	if tag == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Tag parameter is required"})
+		return
+	}
+
+	reg, exists := h.config.GetRegistry(registryName)
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Registry not found"})
+		return
+	}
+
+	client := h.clients.Get(registryName, reg)
+	history, err := client.GetImageHistory(c.Request.Context(), repository, tag, c.Query("platform"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, history)
+}
+
+func (h *Handler) GetLayerFiles(c *gin.Context) {
+	registryName := extractRegistryParam(c)
+	repository := extractRepositoryParam(c)
+	digest := c.Query("digest")
+
+	if registryName == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Registry parameter is required"})
+		return
+	}
+
+	if repository == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Repository parameter is required"})
+		return
+	}
+
+	if digest == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Digest parameter is required"})
+		return
+	}
+
+	reg, exists := h.config.GetRegistry(registryName)
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Registry not found"})
+		return
+	}
+
+	disableWriteDeadline(c)
+
+	client := h.clients.Get(registryName, reg)
+	encoder := json.NewEncoder(c.Writer)
+	started := false
+
+	// Записи отдаются потоком NDJSON по мере распаковки слоя
+	err := client.ListLayerFiles(c.Request.Context(), repository, digest, func(entry registry.LayerEntry) error {
+		if !started {
+			c.Header("Content-Type", "application/x-ndjson")
+			c.Status(http.StatusOK)
+			started = true
+		}
+		if err := encoder.Encode(entry); err != nil {
+			return err
+		}
+		c.Writer.Flush()
+		return nil
+	})
+
+	if err != nil && !started {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err != nil {
+		// Заголовки уже отправлены, ошибку передаем последней строкой потока
+		_ = encoder.Encode(gin.H{"error": err.Error()})
+		c.Writer.Flush()
+		return
+	}
+
+	if !started {
+		c.Header("Content-Type", "application/x-ndjson")
+		c.Status(http.StatusOK)
+	}
+}
+
+func (h *Handler) GetFilesystem(c *gin.Context) {
+	registryName := extractRegistryParam(c)
+	repository := extractRepositoryParam(c)
+	tag := c.Query("tag")
+
+	if registryName == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Registry parameter is required"})
+		return
+	}
+
+	if repository == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Repository parameter is required"})
+		return
+	}
+
+	if tag == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Tag parameter is required"})
+		return
+	}
+
+	reg, exists := h.config.GetRegistry(registryName)
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Registry not found"})
+		return
+	}
+
+	disableWriteDeadline(c)
+
+	client := h.clients.Get(registryName, reg)
+	filesystem, err := client.GetFilesystem(c.Request.Context(), repository, tag, c.Query("platform"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, filesystem)
+}
+
+func (h *Handler) GetFile(c *gin.Context) {
+	registryName := extractRegistryParam(c)
+	repository := extractRepositoryParam(c)
+	tag := c.Query("tag")
+	filePath := c.Query("path")
+
+	if registryName == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Registry parameter is required"})
+		return
+	}
+
+	if repository == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Repository parameter is required"})
+		return
+	}
+
+	if tag == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Tag parameter is required"})
+		return
+	}
+
+	if filePath == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Path parameter is required"})
+		return
+	}
+
+	reg, exists := h.config.GetRegistry(registryName)
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Registry not found"})
+		return
+	}
+
+	disableWriteDeadline(c)
+
+	client := h.clients.Get(registryName, reg)
+	started := false
+	err := client.GetFile(c.Request.Context(), repository, tag, c.Query("platform"), filePath, func(entry registry.LayerEntry, content io.Reader) error {
+		started = true
+		c.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": path.Base(entry.Path)}))
+		c.Header("X-Layer-Digest", entry.Layer)
+		c.DataFromReader(http.StatusOK, entry.Size, "application/octet-stream", content, nil)
+		return nil
+	})
+
+	if err != nil && !started {
+		status := http.StatusInternalServerError
+		if errors.Is(err, registry.ErrFileNotFound) {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+	}
+}
+
+func (h *Handler) GetImageDiff(c *gin.Context) {
+	registryName := extractRegistryParam(c)
+	repository := extractRepositoryParam(c)
+	from := c.Query("from")
+	to := c.Query("to")
+
+	if registryName == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Registry parameter is required"})
+		return
+	}
+
+	if repository == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Repository parameter is required"})
+		return
+	}
+
+	if from == "" || to == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "From and to parameters are required"})
+		return
+	}
+
+	reg, exists := h.config.GetRegistry(registryName)
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Registry not found"})
+		return
+	}
+
+	compareFiles := c.Query("files") == "true"
+	if compareFiles {
+		disableWriteDeadline(c)
+	}
+
+	client := h.clients.Get(registryName, reg)
+	diff, err := client.CompareImages(c.Request.Context(), repository, from, to, c.Query("platform"), compareFiles)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, diff)
+}
+
 func (h *Handler) ServeIndex(c *gin.Context) {
 	c.HTML(http.StatusOK, "index.html", gin.H{
 		"title": "RegLite - Docker Registry UI",
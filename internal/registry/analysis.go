@@ -0,0 +1,142 @@
+package registry
+
+import (
+	"context"
+	"fmt"
+	"sort"
+)
+
+// SharedLayer blob, используемый несколькими репозиториями
+type SharedLayer struct {
+	Digest            string   `json:"digest"`
+	Size              int64    `json:"size"`
+	RepositoriesCount int      `json:"repositoriesCount"`
+	Repositories      []string `json:"repositories"`
+	SavedSize         int64    `json:"savedSize"` // Экономия за счет дедупликации: size * (repositoriesCount - 1)
+}
+
+// RepositoryUsage объем хранения одного репозитория в масштабе реестра
+type RepositoryUsage struct {
+	Name          string `json:"name"`
+	TagsCount     int    `json:"tagsCount"`
+	ImagesSize    int64  `json:"imagesSize"`    // Сумма размеров образов тегов
+	UniqueSize    int64  `json:"uniqueSize"`    // Уникальные blob репозитория
+	ExclusiveSize int64  `json:"exclusiveSize"` // Blob, которые не используются другими репозиториями
+}
+
+// StorageReport анализ хранения реестра: общий объем, общие слои и крупнейшие репозитории
+type StorageReport struct {
+	RepositoriesCount int               `json:"repositoriesCount"`
+	TagsCount         int               `json:"tagsCount"`
+	BlobsCount        int               `json:"blobsCount"`
+	UniqueSize        int64             `json:"uniqueSize"`       // Уникальные blob всего реестра
+	RepositoriesSize  int64             `json:"repositoriesSize"` // Сумма объемов репозиториев без дедупликации между ними
+	ImagesSize        int64             `json:"imagesSize"`       // Сумма размеров образов всех тегов
+	SharedLayers      []SharedLayer     `json:"sharedLayers"`     // Top N blob по количеству репозиториев
+	TopRepositories   []RepositoryUsage `json:"topRepositories"`  // Top N репозиториев по exclusiveSize
+	Errors            map[string]string `json:"errors,omitempty"` // Репозитории и теги (repo:tag), которые не удалось учесть
+}
+
+// AnalyzeStorage обходит каталог реестра и считает объем хранения с учетом blob,
+// общих для нескольких репозиториев. progress вызывается после каждого репозитория
+func (c *Client) AnalyzeStorage(ctx context.Context, topN int, progress func(done, total int)) (*StorageReport, error) {
+	catalog, err := c.GetCatalog(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get catalog: %w", err)
+	}
+
+	report := &StorageReport{
+		RepositoriesCount: len(catalog.Repositories),
+		SharedLayers:      []SharedLayer{},
+		TopRepositories:   []RepositoryUsage{},
+		Errors:            map[string]string{},
+	}
+
+	blobSizes := make(map[string]int64)
+	blobRepositories := make(map[string][]string)
+	repositoryBlobs := make(map[string]map[string]int64)
+	var usages []RepositoryUsage
+
+	for i, repository := range catalog.Repositories {
+		tags, err := c.GetTags(ctx, repository)
+		if err != nil {
+			report.Errors[repository] = err.Error()
+		} else {
+			storage, err := c.GetRepositoryStorage(ctx, repository, tags.Tags)
+			if err != nil {
+				return nil, err
+			}
+
+			usages = append(usages, RepositoryUsage{
+				Name:       repository,
+				TagsCount:  len(tags.Tags),
+				ImagesSize: storage.ImagesSize,
+				UniqueSize: storage.UniqueSize,
+			})
+			repositoryBlobs[repository] = storage.Blobs
+			for _, failed := range storage.FailedTags {
+				report.Errors[repository+":"+failed.Tag] = failed.Error
+			}
+
+			report.TagsCount += len(tags.Tags)
+			report.ImagesSize += storage.ImagesSize
+			report.RepositoriesSize += storage.UniqueSize
+			for digest, size := range storage.Blobs {
+				blobSizes[digest] = size
+				blobRepositories[digest] = append(blobRepositories[digest], repository)
+			}
+		}
+
+		if progress != nil {
+			progress(i+1, len(catalog.Repositories))
+		}
+	}
+
+	report.BlobsCount = len(blobSizes)
+	for digest, size := range blobSizes {
+		report.UniqueSize += size
+
+		if repositories := blobRepositories[digest]; len(repositories) > 1 {
+			report.SharedLayers = append(report.SharedLayers, SharedLayer{
+				Digest:            digest,
+				Size:              size,
+				RepositoriesCount: len(repositories),
+				Repositories:      repositories,
+				SavedSize:         size * int64(len(repositories)-1),
+			})
+		}
+	}
+
+	for i := range usages {
+		for digest, size := range repositoryBlobs[usages[i].Name] {
+			if len(blobRepositories[digest]) == 1 {
+				usages[i].ExclusiveSize += size
+			}
+		}
+	}
+
+	sort.Slice(report.SharedLayers, func(i, j int) bool {
+		a, b := report.SharedLayers[i], report.SharedLayers[j]
+		if a.RepositoriesCount != b.RepositoriesCount {
+			return a.RepositoriesCount > b.RepositoriesCount
+		}
+		if a.Size != b.Size {
+			return a.Size > b.Size
+		}
+		return a.Digest < b.Digest
+	})
+	sort.Slice(usages, func(i, j int) bool {
+		if usages[i].ExclusiveSize != usages[j].ExclusiveSize {
+			return usages[i].ExclusiveSize > usages[j].ExclusiveSize
+		}
+		return usages[i].Name < usages[j].Name
+	})
+
+	if topN > 0 {
+		report.SharedLayers = report.SharedLayers[:min(topN, len(report.SharedLayers))]
+		usages = usages[:min(topN, len(usages))]
+	}
+	report.TopRepositories = append(report.TopRepositories, usages...)
+
+	return report, nil
+}
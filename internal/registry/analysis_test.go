@@ -0,0 +1,46 @@
+package registry
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+func TestAnalyzeStorageSharedLayers(t *testing.T) {
+	tr := newTestRegistry(t)
+	base := []testFile{{Name: "etc/os-release", Body: "base"}}
+	tr.addImage("api", "v1", base, []testFile{{Name: "api", Body: "api"}})
+	tr.addImage("web", "v1", base, []testFile{{Name: "web", Body: "web"}})
+
+	report, err := tr.client().AnalyzeStorage(context.Background(), 10, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if report.RepositoriesCount != 2 || report.TagsCount != 2 || len(report.Errors) != 0 {
+		t.Errorf("report = %+v", report)
+	}
+	// Базовый слой и одинаковый config общие для обоих репозиториев
+	if len(report.SharedLayers) != 2 || report.SharedLayers[0].RepositoriesCount != 2 {
+		t.Errorf("shared layers = %+v, want base layer and config", report.SharedLayers)
+	}
+	if report.UniqueSize >= report.RepositoriesSize {
+		t.Errorf("UniqueSize = %d, want less than RepositoriesSize %d", report.UniqueSize, report.RepositoriesSize)
+	}
+}
+
+func TestAnalyzeStorageReportsFailedTags(t *testing.T) {
+	tr := newTestRegistry(t)
+	tr.addImage("api", "v1", []testFile{{Name: "api", Body: "v1"}})
+	tr.addImage("api", "v2", []testFile{{Name: "api", Body: "v2"}})
+	tr.fail("api", "v2", http.StatusTooManyRequests)
+
+	report, err := tr.client().AnalyzeStorage(context.Background(), 10, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, reported := report.Errors["api:v2"]; !reported || len(report.Errors) != 1 {
+		t.Errorf("Errors = %v, want failure of api:v2", report.Errors)
+	}
+}
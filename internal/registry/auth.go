@@ -0,0 +1,290 @@
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Минимальное время жизни токена по спецификации Docker token auth
+const defaultTokenLifetime = 60 * time.Second
+
+// Запас времени, за который токен считается истекшим до фактического expiry
+const tokenExpiryLeeway = 5 * time.Second
+
+// client_id, передаваемый token-серверу при обмене refresh токена
+const oauthClientID = "reglite"
+
+// authChallenge описывает параметры из заголовка WWW-Authenticate
+type authChallenge struct {
+	Scheme string
+	Realm  string
+	Params map[string]string
+}
+
+// tokenResponse ответ token-сервера
+type tokenResponse struct {
+	Token       string `json:"token"`
+	AccessToken string `json:"access_token"`
+	ExpiresIn   int    `json:"expires_in"`
+	IssuedAt    string `json:"issued_at"`
+}
+
+type bearerToken struct {
+	value     string
+	expiresAt time.Time
+}
+
+func (t *bearerToken) valid() bool {
+	return t != nil && t.value != "" && time.Now().Add(tokenExpiryLeeway).Before(t.expiresAt)
+}
+
+// tokenCache кэширует bearer токены по scope
+type tokenCache struct {
+	mu        sync.Mutex
+	challenge *authChallenge
+	tokens    map[string]*bearerToken
+}
+
+func newTokenCache() *tokenCache {
+	return &tokenCache{tokens: make(map[string]*bearerToken)}
+}
+
+func (tc *tokenCache) get(scope string) (string, bool) {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+
+	token, ok := tc.tokens[scope]
+	if !ok {
+		return "", false
+	}
+	if !token.valid() {
+		delete(tc.tokens, scope)
+		return "", false
+	}
+	return token.value, true
+}
+
+func (tc *tokenCache) set(scope string, token *bearerToken) {
+	tc.mu.Lock()
+	tc.tokens[scope] = token
+	tc.mu.Unlock()
+}
+
+func (tc *tokenCache) invalidate(scope string) {
+	tc.mu.Lock()
+	delete(tc.tokens, scope)
+	tc.mu.Unlock()
+}
+
+func (tc *tokenCache) getChallenge() *authChallenge {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	return tc.challenge
+}
+
+func (tc *tokenCache) setChallenge(challenge *authChallenge) {
+	tc.mu.Lock()
+	tc.challenge = challenge
+	tc.mu.Unlock()
+}
+
+// parseAuthChallenge разбирает заголовок вида
+// Bearer realm="https://auth.example.com/token",service="registry",scope="repository:foo:pull"
+func parseAuthChallenge(header string) (*authChallenge, error) {
+	header = strings.TrimSpace(header)
+	if header == "" {
+		return nil, fmt.Errorf("empty WWW-Authenticate header")
+	}
+
+	scheme, rest, _ := strings.Cut(header, " ")
+	challenge := &authChallenge{
+		Scheme: strings.ToLower(scheme),
+		Params: make(map[string]string),
+	}
+
+	for rest = strings.TrimSpace(rest); rest != ""; rest = strings.TrimSpace(rest) {
+		key, value, ok := strings.Cut(rest, "=")
+		if !ok {
+			break
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+
+		if strings.HasPrefix(value, `"`) {
+			// Значение в кавычках может содержать запятые (например, в scope)
+			var b strings.Builder
+			i := 1
+			for ; i < len(value); i++ {
+				if value[i] == '\\' && i+1 < len(value) {
+					i++
+					b.WriteByte(value[i])
+					continue
+				}
+				if value[i] == '"' {
+					break
+				}
+				b.WriteByte(value[i])
+			}
+			challenge.Params[key] = b.String()
+			rest = value[min(i+1, len(value)):]
+		} else {
+			token, remainder, _ := strings.Cut(value, ",")
+			challenge.Params[key] = strings.TrimSpace(token)
+			rest = remainder
+		}
+		rest = strings.TrimPrefix(strings.TrimSpace(rest), ",")
+	}
+
+	challenge.Realm = challenge.Params["realm"]
+	if challenge.Scheme == "bearer" && challenge.Realm == "" {
+		return nil, fmt.Errorf("bearer challenge without realm")
+	}
+
+	return challenge, nil
+}
+
+// requestScope определяет scope, который нужен для запроса к указанному пути
+func requestScope(method, path string) string {
+	path = strings.SplitN(path, "?", 2)[0]
+	if path == "/v2/_catalog" {
+		return "registry:catalog:*"
+	}
+
+	name := strings.TrimPrefix(path, "/v2/")
+	for _, marker := range []string{"/tags/", "/manifests/", "/blobs/", "/referrers/"} {
+		if idx := strings.LastIndex(name, marker); idx > 0 {
+			action := "pull"
+			if method == http.MethodDelete {
+				action = "delete"
+			}
+			return fmt.Sprintf("repository:%s:%s", name[:idx], action)
+		}
+	}
+
+	return ""
+}
+
+// fetchToken получает bearer токен у token-сервера из challenge
+func (c *Client) fetchToken(ctx context.Context, challenge *authChallenge, scope string) (*bearerToken, error) {
+	if c.registry.IdentityToken != "" {
+		return c.exchangeRefreshToken(ctx, challenge, scope)
+	}
+
+	tokenURL, err := url.Parse(challenge.Realm)
+	if err != nil {
+		return nil, fmt.Errorf("invalid token realm %q: %w", challenge.Realm, err)
+	}
+
+	query := tokenURL.Query()
+	if service := challenge.Params["service"]; service != "" {
+		query.Set("service", service)
+	}
+	if scope != "" {
+		for _, s := range strings.Split(scope, " ") {
+			query.Add("scope", s)
+		}
+	}
+	tokenURL.RawQuery = query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, tokenURL.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	username, password, err := c.registry.GetCredentials()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get credentials: %w", err)
+	}
+	if username != "" && password != "" {
+		req.SetBasicAuth(username, password)
+	}
+
+	return c.doTokenRequest(req)
+}
+
+// exchangeRefreshToken обменивает identity token на bearer токен (OAuth2 grant_type=refresh_token)
+func (c *Client) exchangeRefreshToken(ctx context.Context, challenge *authChallenge, scope string) (*bearerToken, error) {
+	form := url.Values{}
+	form.Set("grant_type", "refresh_token")
+	form.Set("refresh_token", c.registry.IdentityToken)
+	form.Set("client_id", oauthClientID)
+	if service := challenge.Params["service"]; service != "" {
+		form.Set("service", service)
+	}
+	if scope != "" {
+		form.Set("scope", scope)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, challenge.Realm, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	return c.doTokenRequest(req)
+}
+
+// doTokenRequest выполняет запрос к token-серверу и разбирает ответ
+func (c *Client) doTokenRequest(req *http.Request) (*bearerToken, error) {
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, fmt.Errorf("token request failed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("token server returned status %d", resp.StatusCode)
+	}
+
+	var tr tokenResponse
+	if err := json.NewDecoder(resp.Body).Decode(&tr); err != nil {
+		return nil, fmt.Errorf("failed to decode token response: %w", err)
+	}
+
+	return newBearerToken(tr)
+}
+
+// newBearerToken вычисляет время истечения токена из ответа token-сервера
+func newBearerToken(tr tokenResponse) (*bearerToken, error) {
+	value := tr.Token
+	if value == "" {
+		value = tr.AccessToken
+	}
+	if value == "" {
+		return nil, fmt.Errorf("token server returned empty token")
+	}
+
+	lifetime := time.Duration(tr.ExpiresIn) * time.Second
+	if lifetime < defaultTokenLifetime {
+		lifetime = defaultTokenLifetime
+	}
+
+	issuedAt := time.Now()
+	if tr.IssuedAt != "" {
+		if t, err := time.Parse(time.RFC3339, tr.IssuedAt); err == nil && t.Before(issuedAt) {
+			issuedAt = t
+		}
+	}
+
+	return &bearerToken{value: value, expiresAt: issuedAt.Add(lifetime)}, nil
+}
+
+// bearerTokenFor возвращает токен для scope из кэша или запрашивает новый
+func (c *Client) bearerTokenFor(ctx context.Context, challenge *authChallenge, scope string) (string, error) {
+	if token, ok := c.tokens.get(scope); ok {
+		return token, nil
+	}
+
+	token, err := c.fetchToken(ctx, challenge, scope)
+	if err != nil {
+		return "", err
+	}
+	c.tokens.set(scope, token)
+
+	return token.value, nil
+}
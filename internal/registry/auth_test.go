@@ -0,0 +1,317 @@
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/reglite/reglite/internal/config"
+)
+
+func TestParseAuthChallenge(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		scheme string
+		params map[string]string
+	}{
+		{
+			name:   "bearer",
+			header: `Bearer realm="https://auth.example.com/token",service="registry.example.com",scope="repository:app:pull"`,
+			scheme: "bearer",
+			params: map[string]string{"realm": "https://auth.example.com/token", "service": "registry.example.com", "scope": "repository:app:pull"},
+		},
+		{
+			name:   "quoted commas",
+			header: `Bearer realm="https://auth.example.com/token", scope="repository:app:pull,push repository:base:pull"`,
+			scheme: "bearer",
+			params: map[string]string{"realm": "https://auth.example.com/token", "scope": "repository:app:pull,push repository:base:pull"},
+		},
+		{
+			name:   "escapes",
+			header: `Bearer realm="https://auth.example.com/token",error="insufficient_scope",error_description="say \"hi\" \\ bye"`,
+			scheme: "bearer",
+			params: map[string]string{"realm": "https://auth.example.com/token", "error": "insufficient_scope", "error_description": `say "hi" \ bye`},
+		},
+		{
+			name:   "unquoted values",
+			header: `Bearer realm=https://auth.example.com/token,service=registry`,
+			scheme: "bearer",
+			params: map[string]string{"realm": "https://auth.example.com/token", "service": "registry"},
+		},
+		{
+			name:   "basic",
+			header: `Basic realm="Registry Realm"`,
+			scheme: "basic",
+			params: map[string]string{"realm": "Registry Realm"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			challenge, err := parseAuthChallenge(tt.header)
+			if err != nil {
+				t.Fatalf("parseAuthChallenge: %v", err)
+			}
+			if challenge.Scheme != tt.scheme {
+				t.Errorf("Scheme = %q, want %q", challenge.Scheme, tt.scheme)
+			}
+			if challenge.Realm != tt.params["realm"] {
+				t.Errorf("Realm = %q, want %q", challenge.Realm, tt.params["realm"])
+			}
+			for key, want := range tt.params {
+				if got := challenge.Params[key]; got != want {
+					t.Errorf("Params[%q] = %q, want %q", key, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestParseAuthChallengeErrors(t *testing.T) {
+	for _, header := range []string{"", "   ", `Bearer service="registry"`} {
+		if _, err := parseAuthChallenge(header); err == nil {
+			t.Errorf("parseAuthChallenge(%q) succeeded, want error", header)
+		}
+	}
+}
+
+func TestRequestScope(t *testing.T) {
+	tests := []struct {
+		method, path, want string
+	}{
+		{http.MethodGet, "/v2/_catalog?n=100", "registry:catalog:*"},
+		{http.MethodGet, "/v2/org/app/tags/list", "repository:org/app:pull"},
+		{http.MethodGet, "/v2/app/manifests/latest", "repository:app:pull"},
+		{http.MethodDelete, "/v2/app/manifests/sha256:abc", "repository:app:delete"},
+		{http.MethodGet, "/v2/app/blobs/sha256:abc", "repository:app:pull"},
+		{http.MethodGet, "/v2/", ""},
+	}
+
+	for _, tt := range tests {
+		if got := requestScope(tt.method, tt.path); got != tt.want {
+			t.Errorf("requestScope(%s, %s) = %q, want %q", tt.method, tt.path, got, tt.want)
+		}
+	}
+}
+
+// tokenRegistry реестр с token-сервером: токены выдаются на scope, реестр принимает
+// только токены текущего поколения; revoke делает выданные токены недействительными.
+// Токен registryToken реестр принимает без обращения к token-серверу
+type tokenRegistry struct {
+	server *httptest.Server
+
+	mu            sync.Mutex
+	generation    int
+	issuedAt      func() time.Time
+	registryToken string
+	tokenScopes   []string
+	basicAuth     []string
+	refreshForms  []url.Values // Формы POST-запросов обмена refresh token
+}
+
+func newTokenRegistry(t *testing.T) *tokenRegistry {
+	tr := &tokenRegistry{issuedAt: time.Now}
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		tr.mu.Lock()
+		defer tr.mu.Unlock()
+
+		if err := r.ParseForm(); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if r.Method == http.MethodPost {
+			tr.refreshForms = append(tr.refreshForms, r.PostForm)
+		}
+
+		scope := r.Form.Get("scope")
+		tr.tokenScopes = append(tr.tokenScopes, scope)
+		if username, password, ok := r.BasicAuth(); ok {
+			tr.basicAuth = append(tr.basicAuth, username+":"+password)
+		}
+		if r.Form.Get("service") != "test-registry" {
+			http.Error(w, "unknown service", http.StatusBadRequest)
+			return
+		}
+
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"token":      fmt.Sprintf("%s@%d", scope, tr.generation),
+			"expires_in": 300,
+			"issued_at":  tr.issuedAt().Format(time.RFC3339),
+		})
+	})
+
+	mux.HandleFunc("/v2/", func(w http.ResponseWriter, r *http.Request) {
+		scope := requestScope(r.Method, r.URL.Path)
+
+		tr.mu.Lock()
+		want := fmt.Sprintf("Bearer %s@%d", scope, tr.generation)
+		static := tr.registryToken
+		tr.mu.Unlock()
+
+		authorization := r.Header.Get("Authorization")
+		if authorization != want && (static == "" || authorization != "Bearer "+static) {
+			w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="%s/token",service="test-registry",scope="%s"`, tr.server.URL, scope))
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte(`{"tags":["latest"]}`))
+	})
+
+	tr.server = httptest.NewServer(mux)
+	t.Cleanup(tr.server.Close)
+	return tr
+}
+
+func (tr *tokenRegistry) revoke() {
+	tr.mu.Lock()
+	tr.generation++
+	tr.mu.Unlock()
+}
+
+func (tr *tokenRegistry) tokenRequests() []string {
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+	return append([]string(nil), tr.tokenScopes...)
+}
+
+func (tr *tokenRegistry) get(t *testing.T, client *Client, repository string) {
+	t.Helper()
+	resp, err := client.makeRequest(context.Background(), http.MethodGet, "/v2/"+repository+"/tags/list")
+	if err != nil {
+		t.Fatalf("request %s: %v", repository, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("request %s: status %d", repository, resp.StatusCode)
+	}
+}
+
+func TestBearerTokenCachedPerScope(t *testing.T) {
+	tr := newTokenRegistry(t)
+	client := NewClient(config.Registry{URL: tr.server.URL, Username: "user", Password: "secret"})
+
+	tr.get(t, client, "app")
+	tr.get(t, client, "app")
+	tr.get(t, client, "base")
+	tr.get(t, client, "app")
+	tr.get(t, client, "base")
+
+	want := []string{"repository:app:pull", "repository:base:pull"}
+	if got := tr.tokenRequests(); strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("token requests = %v, want %v", got, want)
+	}
+
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+	for _, credentials := range tr.basicAuth {
+		if credentials != "user:secret" {
+			t.Errorf("token request credentials = %q, want user:secret", credentials)
+		}
+	}
+	if len(tr.basicAuth) != 2 {
+		t.Errorf("token requests with credentials = %d, want 2", len(tr.basicAuth))
+	}
+}
+
+func TestBearerTokenExpiry(t *testing.T) {
+	tr := newTokenRegistry(t)
+	// Токен выдан почти 5 минут назад и истекает в пределах запаса tokenExpiryLeeway
+	tr.issuedAt = func() time.Time { return time.Now().Add(-298 * time.Second) }
+	client := NewClient(config.Registry{URL: tr.server.URL})
+
+	tr.get(t, client, "app")
+	tr.get(t, client, "app")
+
+	if got := len(tr.tokenRequests()); got != 2 {
+		t.Errorf("token requests = %d, want 2 (expired token must not be reused)", got)
+	}
+}
+
+func TestBearerTokenRefreshedAfterUnauthorized(t *testing.T) {
+	tr := newTokenRegistry(t)
+	client := NewClient(config.Registry{URL: tr.server.URL})
+
+	tr.get(t, client, "app")
+	// Реестр отзывает токены: запрос с кэшированным токеном получает 401,
+	// клиент запрашивает новый токен и повторяет запрос
+	tr.revoke()
+	tr.get(t, client, "app")
+
+	if got := len(tr.tokenRequests()); got != 2 {
+		t.Errorf("token requests = %d, want 2", got)
+	}
+	if token, ok := client.tokens.get("repository:app:pull"); !ok || token != "repository:app:pull@1" {
+		t.Errorf("cached token = %q, want token of the new generation", token)
+	}
+}
+
+func TestIdentityTokenExchange(t *testing.T) {
+	tr := newTokenRegistry(t)
+	client := NewClient(config.Registry{URL: tr.server.URL, Username: "user", Password: "secret", IdentityToken: "refresh-token"})
+
+	tr.get(t, client, "app")
+	tr.get(t, client, "app")
+
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+	if len(tr.refreshForms) != 1 || len(tr.tokenScopes) != 1 {
+		t.Fatalf("refresh requests = %d of %d token requests, want one POST exchange", len(tr.refreshForms), len(tr.tokenScopes))
+	}
+	want := map[string]string{
+		"grant_type":    "refresh_token",
+		"refresh_token": "refresh-token",
+		"service":       "test-registry",
+		"scope":         "repository:app:pull",
+		"client_id":     oauthClientID,
+	}
+	for key, value := range want {
+		if got := tr.refreshForms[0].Get(key); got != value {
+			t.Errorf("form %s = %q, want %q", key, got, value)
+		}
+	}
+	// Identity token заменяет логин и пароль: basic auth к token-серверу не отправляется
+	if len(tr.basicAuth) != 0 {
+		t.Errorf("token request credentials = %v, want none", tr.basicAuth)
+	}
+}
+
+func TestRegistryTokenSentAsBearer(t *testing.T) {
+	tr := newTokenRegistry(t)
+	tr.registryToken = "static-token"
+	client := NewClient(config.Registry{URL: tr.server.URL, RegistryToken: "static-token"})
+
+	tr.get(t, client, "app")
+	tr.get(t, client, "base")
+
+	if got := tr.tokenRequests(); len(got) != 0 {
+		t.Errorf("token requests = %v, want none with registry token", got)
+	}
+}
+
+func TestNewBearerTokenLifetime(t *testing.T) {
+	token, err := newBearerToken(tokenResponse{AccessToken: "abc", ExpiresIn: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token.value != "abc" {
+		t.Errorf("value = %q, want access_token", token.value)
+	}
+	// Время жизни меньше минимального по спецификации увеличивается до 60s
+	if lifetime := time.Until(token.expiresAt); lifetime < 55*time.Second {
+		t.Errorf("lifetime = %v, want at least %v", lifetime, defaultTokenLifetime)
+	}
+
+	if _, err := newBearerToken(tokenResponse{}); err == nil {
+		t.Error("empty token accepted")
+	}
+}
@@ -1,12 +1,15 @@
 package registry
 
 import (
+	"context"
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"strings"
+	"sync"
+	"sync/atomic"
 
 	"github.com/reglite/reglite/internal/config"
 )
@@ -14,150 +17,277 @@ import (
 type Client struct {
 	registry config.Registry
 	client   *http.Client
+	tokens   *tokenCache
+	setupErr error // Ошибка настройки транспорта (TLS), возвращается каждым запросом
+
+	requests chan struct{} // Семафор одновременных запросов к реестру, размер max_concurrency
+
+	plainHTTP atomic.Bool // Insecure реестр не ответил по HTTPS и работает по HTTP
+
+	rateLimitMutex sync.RWMutex
+	rateLimit      *RateLimit // Последний лимит запросов, сообщенный реестром
+}
+
+// StatusError ошибка с неожиданным HTTP статусом ответа реестра
+type StatusError struct {
+	StatusCode int
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("registry returned status %d", e.StatusCode)
 }
 
 type CatalogResponse struct {
 	Repositories []string `json:"repositories"`
+	Next         string   `json:"next,omitempty"` // курсор следующей страницы (параметр last)
 }
 
 type TagsResponse struct {
 	Name string   `json:"name"`
 	Tags []string `json:"tags"`
+	Next string   `json:"next,omitempty"` // курсор следующей страницы (параметр last)
 }
 
 type ManifestResponse struct {
-	MediaType     string `json:"mediaType"`
-	SchemaVersion int    `json:"schemaVersion"`
-	Tag           string `json:"tag"`
-	Architecture  string `json:"architecture"`
-	Digest        string `json:"digest"`
-	Size          int64  `json:"size"`
-	Created       string `json:"created,omitempty"`
+	MediaType     string             `json:"mediaType"`
+	SchemaVersion int                `json:"schemaVersion"`
+	Tag           string             `json:"tag"`
+	Architecture  string             `json:"architecture"`
+	OS            string             `json:"os,omitempty"`
+	Variant       string             `json:"variant,omitempty"`
+	Digest        string             `json:"digest"`
+	Size          int64              `json:"size"`
+	Created       string             `json:"created,omitempty"`
+	IsIndex       bool               `json:"isIndex,omitempty"`   // Manifest list / OCI index
+	Platforms     []PlatformManifest `json:"platforms,omitempty"` // Платформы multi-arch образа
+
+	ArtifactType    string            `json:"artifactType,omitempty"`    // Тип OCI артефакта (Helm, WASM, SBOM...)
+	ConfigMediaType string            `json:"configMediaType,omitempty"` // Media type config blob
+	Annotations     map[string]string `json:"annotations,omitempty"`
+	Layers          []Descriptor      `json:"layers,omitempty"`
 }
 
 type RepositoryInfo struct {
-	Name            string   `json:"name"`
-	TagsCount       int      `json:"tagsCount"`
-	Tags            []string `json:"tags"`
-	LastModified    string   `json:"lastModified,omitempty"`
-	Description     string   `json:"description,omitempty"`
-	TotalSize       int64    `json:"totalSize,omitempty"`       // Общий размер репозитория
-	SampleSize      int64    `json:"sampleSize,omitempty"`      // Размер образца для оценки
-	SampleTagsCount int      `json:"sampleTagsCount,omitempty"` // Количество тегов в образце
-	IsEstimate      bool     `json:"isEstimate,omitempty"`      // Является ли размер оценкой
+	Name            string     `json:"name"`
+	TagsCount       int        `json:"tagsCount"`
+	Tags            []string   `json:"tags"`
+	LastModified    string     `json:"lastModified,omitempty"`
+	Description     string     `json:"description,omitempty"`
+	TotalSize       int64      `json:"totalSize,omitempty"`       // Общий размер репозитория (уникальные blob)
+	ImagesSize      int64      `json:"imagesSize,omitempty"`      // Сумма размеров образов без учета общих слоев
+	TagSizes        []TagSize  `json:"tagSizes,omitempty"`        // Размеры тегов, если размер точный
+	SampleSize      int64      `json:"sampleSize,omitempty"`      // Размер образца для оценки
+	SampleTagsCount int        `json:"sampleTagsCount,omitempty"` // Количество тегов в образце
+	IsEstimate      bool       `json:"isEstimate,omitempty"`      // Является ли размер оценкой
+	FailedTags      []TagError `json:"failedTags,omitempty"`      // Теги, не учтенные в размере из-за ошибок
 }
 
 func NewClient(registry config.Registry) *Client {
-	return &Client{
+	c := &Client{
 		registry: registry,
-		client:   &http.Client{},
+		tokens:   newTokenCache(),
 	}
+	c.requests = make(chan struct{}, c.concurrency())
+	transport, err := c.newTransport()
+	c.client = &http.Client{Transport: transport}
+	c.setupErr = err
+	return c
 }
 
-func (c *Client) makeRequest(method, path string) (*http.Response, error) {
-	url := strings.TrimSuffix(c.registry.URL, "/") + path
+func (c *Client) makeRequest(ctx context.Context, method, path string) (*http.Response, error) {
+	if err := c.acquire(ctx); err != nil {
+		return nil, err
+	}
+	defer c.release()
+
+	scope := requestScope(method, path)
 
-	req, err := http.NewRequest(method, url, nil)
+	req, err := c.newRequest(ctx, method, path)
 	if err != nil {
 		return nil, err
 	}
 
-	username, password, err := c.registry.GetCredentials()
-	if err != nil {
-		return nil, fmt.Errorf("failed to get credentials: %w", err)
+	// Registry token передается реестру как есть, без обращения к token-серверу
+	if c.registry.RegistryToken != "" {
+		req.Header.Set("Authorization", "Bearer "+c.registry.RegistryToken)
+		return c.do(req)
 	}
 
-	if username != "" && password != "" {
-		auth := base64.StdEncoding.EncodeToString([]byte(username + ":" + password))
-		req.Header.Set("Authorization", "Basic "+auth)
+	// Если реестр уже требовал bearer токен, сразу запрашиваем его для нужного scope
+	if challenge := c.tokens.getChallenge(); challenge != nil {
+		token, err := c.bearerTokenFor(ctx, challenge, scope)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+	} else if err := c.setBasicAuth(req); err != nil {
+		return nil, err
 	}
 
-	if strings.Contains(path, "/manifests/") {
-		req.Header.Set("Accept", "application/vnd.docker.distribution.manifest.v2+json")
+	resp, err := c.do(req)
+	if err != nil || resp.StatusCode != http.StatusUnauthorized {
+		return resp, err
 	}
 
-	return c.client.Do(req)
-}
+	challenge, err := parseAuthChallenge(resp.Header.Get("WWW-Authenticate"))
+	if err != nil || challenge.Scheme != "bearer" {
+		return resp, nil
+	}
+	_ = resp.Body.Close()
 
-func (c *Client) GetCatalog() (*CatalogResponse, error) {
-	resp, err := c.makeRequest("GET", "/v2/_catalog")
+	c.tokens.setChallenge(challenge)
+	c.tokens.invalidate(scope)
+
+	tokenScope := challenge.Params["scope"]
+	if tokenScope == "" {
+		tokenScope = scope
+	}
+	token, err := c.fetchToken(ctx, challenge, tokenScope)
 	if err != nil {
 		return nil, err
 	}
-	defer func() { _ = resp.Body.Close() }()
+	c.tokens.set(scope, token)
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("registry returned status %d", resp.StatusCode)
+	req, err = c.newRequest(ctx, method, path)
+	if err != nil {
+		return nil, err
 	}
+	req.Header.Set("Authorization", "Bearer "+token.value)
 
-	var catalog CatalogResponse
-	if err := json.NewDecoder(resp.Body).Decode(&catalog); err != nil {
+	return c.do(req)
+}
+
+// newRequest создает запрос к реестру без заголовков авторизации
+func (c *Client) newRequest(ctx context.Context, method, path string) (*http.Request, error) {
+	url := c.baseURL() + path
+
+	req, err := http.NewRequestWithContext(ctx, method, url, nil)
+	if err != nil {
 		return nil, err
 	}
 
-	return &catalog, nil
+	if strings.Contains(path, "/manifests/") {
+		req.Header.Set("Accept", strings.Join(manifestAcceptTypes, ", "))
+	}
+	if strings.Contains(path, "/referrers/") {
+		req.Header.Set("Accept", MediaTypeOCIIndex)
+	}
+
+	return req, nil
 }
 
-func (c *Client) GetTags(repository string) (*TagsResponse, error) {
-	path := fmt.Sprintf("/v2/%s/tags/list", repository)
-	resp, err := c.makeRequest("GET", path)
+// setBasicAuth добавляет Basic авторизацию, если заданы учетные данные
+func (c *Client) setBasicAuth(req *http.Request) error {
+	username, password, err := c.registry.GetCredentials()
 	if err != nil {
-		return nil, err
+		return fmt.Errorf("failed to get credentials: %w", err)
 	}
-	defer func() { _ = resp.Body.Close() }()
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("registry returned status %d", resp.StatusCode)
+	if username != "" && password != "" {
+		auth := base64.StdEncoding.EncodeToString([]byte(username + ":" + password))
+		req.Header.Set("Authorization", "Basic "+auth)
 	}
 
-	var tags TagsResponse
-	if err := json.NewDecoder(resp.Body).Decode(&tags); err != nil {
+	return nil
+}
+
+// GetCatalog возвращает все репозитории реестра: из repositories в inventory, если
+// список задан, иначе из _catalog (Docker Hub API для Docker Hub), проходя по всем страницам
+func (c *Client) GetCatalog(ctx context.Context) (*CatalogResponse, error) {
+	if len(c.registry.Repositories) > 0 {
+		return c.configuredCatalog(ctx)
+	}
+	return c.registryCatalog(ctx)
+}
+
+// registryCatalog возвращает все репозитории, которые сообщает сам реестр
+func (c *Client) registryCatalog(ctx context.Context) (*CatalogResponse, error) {
+	if c.registry.IsDockerHub() {
+		return c.getHubCatalog(ctx)
+	}
+
+	catalog := &CatalogResponse{Repositories: []string{}}
+
+	err := getAllPages(ctx, c, "/v2/_catalog", func(page *CatalogResponse) {
+		catalog.Repositories = append(catalog.Repositories, page.Repositories...)
+	})
+	if err != nil {
 		return nil, err
 	}
 
-	return &tags, nil
+	return catalog, nil
 }
 
-func (c *Client) GetManifest(repository, tag string) (*ManifestResponse, error) {
-	path := fmt.Sprintf("/v2/%s/manifests/%s", repository, tag)
-	resp, err := c.makeRequest("GET", path)
+// GetCatalogPage возвращает одну страницу каталога размером n после репозитория last
+func (c *Client) GetCatalogPage(ctx context.Context, n int, last string) (*CatalogResponse, error) {
+	// Списки из inventory и Docker Hub API разбиваются на страницы локально
+	if len(c.registry.Repositories) > 0 || c.registry.IsDockerHub() {
+		catalog, err := c.GetCatalog(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return pageCatalog(catalog, n, last), nil
+	}
+
+	var catalog CatalogResponse
+	next, err := c.getPage(ctx, pagePath("/v2/_catalog", n, last), &catalog)
 	if err != nil {
 		return nil, err
 	}
-	defer func() { _ = resp.Body.Close() }()
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("registry returned status %d", resp.StatusCode)
+	catalog.Next = pageCursor(next)
+	if catalog.Repositories == nil {
+		catalog.Repositories = []string{}
 	}
 
-	digest := resp.Header.Get("Docker-Content-Digest")
-	if digest == "" {
-		digest = resp.Header.Get("Docker-Digest")
-	}
-	if digest == "" {
-		digest = resp.Header.Get("ETag")
-		if len(digest) > 2 && digest[0] == '"' && digest[len(digest)-1] == '"' {
-			digest = digest[1 : len(digest)-1]
+	return &catalog, nil
+}
+
+// GetTags возвращает все теги репозитория, проходя по всем страницам
+func (c *Client) GetTags(ctx context.Context, repository string) (*TagsResponse, error) {
+	tags := &TagsResponse{Name: repository, Tags: []string{}}
+
+	err := getAllPages(ctx, c, fmt.Sprintf("/v2/%s/tags/list", c.repositoryName(repository)), func(page *TagsResponse) {
+		if page.Name != "" {
+			tags.Name = page.Name
 		}
+		tags.Tags = append(tags.Tags, page.Tags...)
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	body, err := io.ReadAll(resp.Body)
+	return tags, nil
+}
+
+// GetTagsPage возвращает одну страницу тегов размером n после тега last
+func (c *Client) GetTagsPage(ctx context.Context, repository string, n int, last string) (*TagsResponse, error) {
+	var tags TagsResponse
+	next, err := c.getPage(ctx, pagePath(fmt.Sprintf("/v2/%s/tags/list", c.repositoryName(repository)), n, last), &tags)
 	if err != nil {
 		return nil, err
 	}
 
-	var manifest ManifestResponse
-	if err := json.Unmarshal(body, &manifest); err != nil {
-		return nil, err
+	tags.Next = pageCursor(next)
+	if tags.Tags == nil {
+		tags.Tags = []string{}
 	}
 
-	manifest.Digest = digest
-	manifest.Tag = tag
+	return &tags, nil
+}
 
-	extractManifestInfo(body, &manifest)
-	c.enrichWithConfigBlob(repository, body, &manifest)
+// GetManifest возвращает манифест по тегу или digest; для multi-arch образов — список платформ
+func (c *Client) GetManifest(ctx context.Context, repository, tag string) (*ManifestResponse, error) {
+	raw, err := c.fetchManifest(ctx, repository, tag)
+	if err != nil {
+		return nil, err
+	}
 
-	return &manifest, nil
+	if isIndexMediaType(raw.MediaType) {
+		return c.buildIndexResponse(ctx, repository, tag, raw)
+	}
+
+	return c.buildImageResponse(ctx, repository, tag, raw)
 }
 
 // extractManifestInfo извлекает дополнительную информацию из манифеста
@@ -223,7 +353,7 @@ func extractManifestInfo(manifestBody []byte, manifest *ManifestResponse) {
 }
 
 // enrichWithConfigBlob получает дополнительную информацию из config blob
-func (c *Client) enrichWithConfigBlob(repository string, manifestBody []byte, manifest *ManifestResponse) {
+func (c *Client) enrichWithConfigBlob(ctx context.Context, repository string, manifestBody []byte, manifest *ManifestResponse) {
 	var manifestData map[string]interface{}
 	if err := json.Unmarshal(manifestBody, &manifestData); err != nil {
 		return
@@ -231,7 +361,7 @@ func (c *Client) enrichWithConfigBlob(repository string, manifestBody []byte, ma
 
 	if config, ok := manifestData["config"].(map[string]interface{}); ok {
 		if digest, ok := config["digest"].(string); ok {
-			configBlob, err := c.getBlob(repository, digest)
+			configBlob, err := c.getBlob(ctx, repository, digest)
 			if err == nil {
 				c.extractFromConfigBlob(configBlob, manifest)
 			}
@@ -240,9 +370,9 @@ func (c *Client) enrichWithConfigBlob(repository string, manifestBody []byte, ma
 }
 
 // getBlob получает blob по digest
-func (c *Client) getBlob(repository, digest string) ([]byte, error) {
-	path := fmt.Sprintf("/v2/%s/blobs/%s", repository, digest)
-	resp, err := c.makeRequest("GET", path)
+func (c *Client) getBlob(ctx context.Context, repository, digest string) ([]byte, error) {
+	path := fmt.Sprintf("/v2/%s/blobs/%s", c.repositoryName(repository), digest)
+	resp, err := c.makeRequest(ctx, "GET", path)
 	if err != nil {
 		return nil, err
 	}
@@ -269,10 +399,19 @@ func (c *Client) extractFromConfigBlob(configBlob []byte, manifest *ManifestResp
 	if architecture, ok := configData["architecture"].(string); ok {
 		manifest.Architecture = architecture
 	}
+
+	if os, ok := configData["os"].(string); ok {
+		manifest.OS = os
+	}
+
+	if variant, ok := configData["variant"].(string); ok {
+		manifest.Variant = variant
+	}
 }
 
-func (c *Client) GetRepositoryInfo(repository string) (*RepositoryInfo, error) {
-	tagsResp, err := c.GetTags(repository)
+// GetRepositoryInfo возвращает теги и размер репозитория; при отмене ctx расчет размера прерывается
+func (c *Client) GetRepositoryInfo(ctx context.Context, repository string) (*RepositoryInfo, error) {
+	tagsResp, err := c.GetTags(ctx, repository)
 	if err != nil {
 		return nil, err
 	}
@@ -284,53 +423,48 @@ func (c *Client) GetRepositoryInfo(repository string) (*RepositoryInfo, error) {
 	}
 
 	if len(tagsResp.Tags) > 0 {
-		totalSize, isEstimate, sampleCount := c.calculateRepositorySize(repository, tagsResp.Tags)
-		info.TotalSize = totalSize
-		info.IsEstimate = isEstimate
-		info.SampleTagsCount = sampleCount
+		if err := c.calculateRepositorySize(ctx, repository, info); err != nil {
+			return nil, err
+		}
 	}
 
 	return info, nil
 }
 
 // calculateRepositorySize вычисляет общий размер репозитория
-func (c *Client) calculateRepositorySize(repository string, tags []string) (int64, bool, int) {
+func (c *Client) calculateRepositorySize(ctx context.Context, repository string, info *RepositoryInfo) error {
 	const maxTagsForExactSize = 35
 	const sampleSizeForEstimate = 10
 
-	tagCount := len(tags)
+	tagCount := len(info.Tags)
 
 	if tagCount <= maxTagsForExactSize {
-		return c.getExactRepositorySize(repository, tags), false, tagCount
+		storage, err := c.GetRepositoryStorage(ctx, repository, info.Tags)
+		if err != nil {
+			return err
+		}
+		info.TotalSize = storage.UniqueSize
+		info.ImagesSize = storage.ImagesSize
+		info.TagSizes = storage.Tags
+		info.FailedTags = storage.FailedTags
+		info.SampleTagsCount = tagCount
 	} else {
-		return c.getEstimatedRepositorySize(repository, tags, sampleSizeForEstimate), true, sampleSizeForEstimate
-	}
-}
-
-// getExactRepositorySize получает точный размер всех тегов
-func (c *Client) getExactRepositorySize(repository string, tags []string) int64 {
-	var totalSize int64
-	uniqueSizes := make(map[string]int64)
-
-	for _, tag := range tags {
-		manifest, err := c.GetManifest(repository, tag)
-		if err == nil {
-			if manifest.Digest != "" {
-				if _, exists := uniqueSizes[manifest.Digest]; !exists {
-					uniqueSizes[manifest.Digest] = manifest.Size
-					totalSize += manifest.Size
-				}
-			} else {
-				totalSize += manifest.Size
-			}
+		totalSize, failedTags, err := c.getEstimatedRepositorySize(ctx, repository, info.Tags, sampleSizeForEstimate)
+		if err != nil {
+			return err
 		}
+		info.TotalSize = totalSize
+		info.FailedTags = failedTags
+		info.IsEstimate = true
+		info.SampleTagsCount = sampleSizeForEstimate
 	}
 
-	return totalSize
+	return nil
 }
 
-// getEstimatedRepositorySize получает приблизительный размер репозитория
-func (c *Client) getEstimatedRepositorySize(repository string, tags []string, sampleSize int) int64 {
+// getEstimatedRepositorySize получает приблизительный размер репозитория и теги образца,
+// которые не удалось учесть
+func (c *Client) getEstimatedRepositorySize(ctx context.Context, repository string, tags []string, sampleSize int) (int64, []TagError, error) {
 	if sampleSize > len(tags) {
 		sampleSize = len(tags)
 	}
@@ -342,18 +476,30 @@ func (c *Client) getEstimatedRepositorySize(repository string, tags []string, sa
 		sampleTags[i] = tags[index]
 	}
 
-	sampleTotalSize := c.getExactRepositorySize(repository, sampleTags)
+	sample, err := c.GetRepositoryStorage(ctx, repository, sampleTags)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	// Общие слои образца уже учтены один раз, масштабируем только уникальную часть тегов
+	var sampleExclusiveSize int64
+	for _, tag := range sample.Tags {
+		sampleExclusiveSize += tag.ExclusiveSize
+	}
+	sharedSize := sample.UniqueSize - sampleExclusiveSize
 
-	estimationFactor := float64(len(tags)) / float64(sampleSize)
-	deduplicationFactor := 0.3 + (0.7 * float64(sampleSize) / float64(len(tags)))
-	estimatedSize := int64(float64(sampleTotalSize) * estimationFactor * deduplicationFactor)
+	if len(sample.Tags) == 0 {
+		return 0, sample.FailedTags, nil
+	}
+	estimationFactor := float64(len(tags)) / float64(len(sample.Tags))
+	estimatedSize := sharedSize + int64(float64(sampleExclusiveSize)*estimationFactor)
 
-	return estimatedSize
+	return estimatedSize, sample.FailedTags, nil
 }
 
-func (c *Client) DeleteManifest(repository, digest string) error {
-	path := fmt.Sprintf("/v2/%s/manifests/%s", repository, digest)
-	resp, err := c.makeRequest("DELETE", path)
+func (c *Client) DeleteManifest(ctx context.Context, repository, digest string) error {
+	path := fmt.Sprintf("/v2/%s/manifests/%s", c.repositoryName(repository), digest)
+	resp, err := c.makeRequest(ctx, "DELETE", path)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,38 @@
+package registry
+
+import (
+	"reflect"
+	"sync"
+
+	"github.com/reglite/reglite/internal/config"
+)
+
+// Pool клиенты реестров по имени из inventory. Клиент со своим транспортом и кэшем
+// токенов живет все время работы процесса и пересоздается при изменении настроек реестра
+type Pool struct {
+	mutex   sync.Mutex
+	clients map[string]*Client
+}
+
+func NewPool() *Pool {
+	return &Pool{clients: make(map[string]*Client)}
+}
+
+// Get возвращает клиент реестра name, создавая его при первом обращении
+// или если настройки реестра отличаются от тех, с которыми он был создан
+func (p *Pool) Get(name string, reg config.Registry) *Client {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if client, exists := p.clients[name]; exists {
+		if reflect.DeepEqual(client.registry, reg) {
+			return client
+		}
+		// Запросы, начатые старым клиентом, дорабатывают; простаивающие соединения закрываем
+		client.client.CloseIdleConnections()
+	}
+
+	client := NewClient(reg)
+	p.clients[name] = client
+	return client
+}
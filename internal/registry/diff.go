@@ -0,0 +1,314 @@
+package registry
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Виды изменений в сравнении образов
+const (
+	ChangeAdded    = "added"
+	ChangeRemoved  = "removed"
+	ChangeModified = "modified"
+
+	LayerShared = "shared"
+)
+
+// DiffImage сторона сравнения образов
+type DiffImage struct {
+	Reference string      `json:"reference"`
+	Digest    string      `json:"digest"` // Digest манифеста платформы
+	Size      int64       `json:"size"`   // config + слои
+	Layers    []LayerDiff `json:"layers"` // Слои по порядку со статусом в сравнении
+}
+
+// LayerDiff слой и его статус в сравнении
+type LayerDiff struct {
+	Descriptor
+	Status string `json:"status"` // shared, added или removed
+}
+
+// ConfigChange изменение одного параметра конфигурации образа
+type ConfigChange struct {
+	Field  string `json:"field"`         // env, labels, entrypoint, cmd и т.д.
+	Key    string `json:"key,omitempty"` // Имя переменной окружения или label
+	Change string `json:"change"`        // added, removed или modified
+	From   string `json:"from,omitempty"`
+	To     string `json:"to,omitempty"`
+}
+
+// FileChange изменение файла между итоговыми файловыми системами образов
+type FileChange struct {
+	Path   string      `json:"path"`
+	Change string      `json:"change"` // added, removed или modified
+	From   *LayerEntry `json:"from,omitempty"`
+	To     *LayerEntry `json:"to,omitempty"`
+}
+
+// ImageDiff результат сравнения двух образов
+type ImageDiff struct {
+	From          DiffImage      `json:"from"`
+	To            DiffImage      `json:"to"`
+	SharedLayers  []Descriptor   `json:"sharedLayers"`
+	AddedLayers   []Descriptor   `json:"addedLayers"`   // Есть только в to
+	RemovedLayers []Descriptor   `json:"removedLayers"` // Есть только в from
+	SizeDelta     int64          `json:"sizeDelta"`     // Изменение размера образа to - from
+	DownloadSize  int64          `json:"downloadSize"`  // Размер новых слоев, которые нужно скачать
+	ConfigChanges []ConfigChange `json:"configChanges"` // Пустой список, если конфигурация не изменилась
+	Files         []FileChange   `json:"files,omitempty"`
+	FilesCompared bool           `json:"filesCompared"`
+}
+
+// CompareImages сравнивает два образа одного репозитория по тегам или digest:
+// общие и новые слои, изменение размера, конфигурацию и (опционально) файлы
+func (c *Client) CompareImages(ctx context.Context, repository, from, to, platform string, compareFiles bool) (*ImageDiff, error) {
+	fromManifest, fromRaw, err := c.resolveImageManifest(ctx, repository, from, platform)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %s: %w", from, err)
+	}
+	toManifest, toRaw, err := c.resolveImageManifest(ctx, repository, to, platform)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %s: %w", to, err)
+	}
+
+	diff := &ImageDiff{
+		From:          newDiffImage(from, fromRaw, fromManifest),
+		To:            newDiffImage(to, toRaw, toManifest),
+		SharedLayers:  []Descriptor{},
+		AddedLayers:   []Descriptor{},
+		RemovedLayers: []Descriptor{},
+		ConfigChanges: []ConfigChange{},
+	}
+	diff.SizeDelta = diff.To.Size - diff.From.Size
+
+	fromDigests := layerDigests(fromManifest.Layers)
+	toDigests := layerDigests(toManifest.Layers)
+
+	for _, layer := range fromManifest.Layers {
+		status := LayerShared
+		if !toDigests[layer.Digest] {
+			status = ChangeRemoved
+			diff.RemovedLayers = append(diff.RemovedLayers, layer)
+		}
+		diff.From.Layers = append(diff.From.Layers, LayerDiff{Descriptor: layer, Status: status})
+	}
+	for _, layer := range toManifest.Layers {
+		status := LayerShared
+		if fromDigests[layer.Digest] {
+			diff.SharedLayers = append(diff.SharedLayers, layer)
+		} else {
+			status = ChangeAdded
+			diff.AddedLayers = append(diff.AddedLayers, layer)
+			diff.DownloadSize += layer.Size
+		}
+		diff.To.Layers = append(diff.To.Layers, LayerDiff{Descriptor: layer, Status: status})
+	}
+
+	if fromManifest.Config.Digest != toManifest.Config.Digest {
+		fromConfig, err := c.imageConfigFromManifest(ctx, repository, fromManifest)
+		if err != nil {
+			return nil, err
+		}
+		toConfig, err := c.imageConfigFromManifest(ctx, repository, toManifest)
+		if err != nil {
+			return nil, err
+		}
+		diff.ConfigChanges = compareConfigs(fromConfig, toConfig)
+	}
+
+	if compareFiles {
+		files, err := c.compareFilesystems(ctx, repository, fromManifest.Layers, toManifest.Layers)
+		if err != nil {
+			return nil, err
+		}
+		diff.Files = files
+		diff.FilesCompared = true
+	}
+
+	return diff, nil
+}
+
+// newDiffImage описывает сторону сравнения
+func newDiffImage(reference string, raw *rawManifest, manifest *imageManifest) DiffImage {
+	image := DiffImage{
+		Reference: reference,
+		Digest:    raw.Digest,
+		Size:      manifest.Config.Size,
+		Layers:    make([]LayerDiff, 0, len(manifest.Layers)),
+	}
+	for _, layer := range manifest.Layers {
+		image.Size += layer.Size
+	}
+	return image
+}
+
+// layerDigests возвращает множество digest слоев
+func layerDigests(layers []Descriptor) map[string]bool {
+	digests := make(map[string]bool, len(layers))
+	for _, layer := range layers {
+		digests[layer.Digest] = true
+	}
+	return digests
+}
+
+// compareFilesystems сравнивает итоговые файловые системы; файлы из общих слоев
+// считаются неизменными. Общая основа (одинаковые нижние слои) собирается один раз,
+// и над ее копиями накладываются слои каждой из сторон
+func (c *Client) compareFilesystems(ctx context.Context, repository string, fromLayers, toLayers []Descriptor) ([]FileChange, error) {
+	base := 0
+	for base < len(fromLayers) && base < len(toLayers) && fromLayers[base].Digest == toLayers[base].Digest {
+		base++
+	}
+
+	cache := make(map[string][]LayerEntry)
+	baseFiles, err := c.mergeLayers(ctx, repository, fromLayers[:base], cache)
+	if err != nil {
+		return nil, err
+	}
+
+	fromFiles := maps.Clone(baseFiles)
+	if err := c.applyLayers(ctx, repository, fromFiles, fromLayers[base:], cache); err != nil {
+		return nil, err
+	}
+	toFiles := baseFiles
+	if err := c.applyLayers(ctx, repository, toFiles, toLayers[base:], cache); err != nil {
+		return nil, err
+	}
+
+	changes := []FileChange{}
+	for filePath, fromEntry := range fromFiles {
+		toEntry, exists := toFiles[filePath]
+		if !exists {
+			changes = append(changes, FileChange{Path: filePath, Change: ChangeRemoved, From: &fromEntry})
+			continue
+		}
+		if fileModified(fromEntry, toEntry) {
+			changes = append(changes, FileChange{Path: filePath, Change: ChangeModified, From: &fromEntry, To: &toEntry})
+		}
+	}
+	for filePath, toEntry := range toFiles {
+		if _, exists := fromFiles[filePath]; !exists {
+			changes = append(changes, FileChange{Path: filePath, Change: ChangeAdded, To: &toEntry})
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Path < changes[j].Path })
+	return changes, nil
+}
+
+// fileModified сравнивает метаданные файла; содержимое без чтения слоев не сравнивается,
+// поэтому файл из другого слоя с теми же размером, правами и временем считается неизменным
+func fileModified(from, to LayerEntry) bool {
+	if from.Layer == to.Layer {
+		return false
+	}
+	if from.Type != to.Type || from.Mode != to.Mode || from.UID != to.UID || from.GID != to.GID {
+		return true
+	}
+	// Время изменения каталогов меняется при любой пересборке и не показательно
+	if from.Type == "dir" {
+		return false
+	}
+	return from.Size != to.Size || from.LinkTarget != to.LinkTarget || !from.ModTime.Equal(to.ModTime)
+}
+
+// compareConfigs сравнивает конфигурации образов по полям
+func compareConfigs(from, to *ImageConfig) []ConfigChange {
+	changes := []ConfigChange{}
+
+	changes = append(changes, compareMaps("env", envMap(from.Env), envMap(to.Env))...)
+	changes = append(changes, compareMaps("labels", from.Labels, to.Labels)...)
+
+	lists := []struct {
+		field    string
+		from, to []string
+	}{
+		{"entrypoint", from.Entrypoint, to.Entrypoint},
+		{"cmd", from.Cmd, to.Cmd},
+		{"exposedPorts", from.ExposedPorts, to.ExposedPorts},
+		{"volumes", from.Volumes, to.Volumes},
+	}
+	for _, list := range lists {
+		if !slices.Equal(list.from, list.to) {
+			changes = append(changes, valueChange(list.field, strings.Join(list.from, " "), strings.Join(list.to, " ")))
+		}
+	}
+
+	values := []struct {
+		field    string
+		from, to string
+	}{
+		{"workingDir", from.WorkingDir, to.WorkingDir},
+		{"user", from.User, to.User},
+		{"stopSignal", from.StopSignal, to.StopSignal},
+		{"platform", configPlatform(from), configPlatform(to)},
+		{"created", from.Created, to.Created},
+		{"author", from.Author, to.Author},
+	}
+	for _, value := range values {
+		if value.from != value.to {
+			changes = append(changes, valueChange(value.field, value.from, value.to))
+		}
+	}
+
+	return changes
+}
+
+// compareMaps сравнивает наборы ключ=значение (env, labels)
+func compareMaps(field string, from, to map[string]string) []ConfigChange {
+	var changes []ConfigChange
+	for _, key := range sortedMapKeys(from) {
+		toValue, exists := to[key]
+		switch {
+		case !exists:
+			changes = append(changes, ConfigChange{Field: field, Key: key, Change: ChangeRemoved, From: from[key]})
+		case toValue != from[key]:
+			changes = append(changes, ConfigChange{Field: field, Key: key, Change: ChangeModified, From: from[key], To: toValue})
+		}
+	}
+	for _, key := range sortedMapKeys(to) {
+		if _, exists := from[key]; !exists {
+			changes = append(changes, ConfigChange{Field: field, Key: key, Change: ChangeAdded, To: to[key]})
+		}
+	}
+	return changes
+}
+
+// valueChange формирует изменение скалярного поля конфигурации
+func valueChange(field, from, to string) ConfigChange {
+	change := ChangeModified
+	switch {
+	case from == "":
+		change = ChangeAdded
+	case to == "":
+		change = ChangeRemoved
+	}
+	return ConfigChange{Field: field, Change: change, From: from, To: to}
+}
+
+// envMap разбирает переменные окружения KEY=VALUE
+func envMap(env []string) map[string]string {
+	values := make(map[string]string, len(env))
+	for _, variable := range env {
+		key, value, _ := strings.Cut(variable, "=")
+		values[key] = value
+	}
+	return values
+}
+
+func configPlatform(config *ImageConfig) string {
+	return Platform{OS: config.OS, Architecture: config.Architecture, Variant: config.Variant}.String()
+}
+
+func sortedMapKeys(values map[string]string) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
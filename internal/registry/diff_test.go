@@ -0,0 +1,54 @@
+package registry
+
+import (
+	"context"
+	"testing"
+)
+
+func TestCompareImagesFilesOverSharedBase(t *testing.T) {
+	tr := newTestRegistry(t)
+	base := []testFile{{Name: "etc/"}, {Name: "etc/foo", Body: "base"}, {Name: "etc/keep", Body: "keep"}, {Name: "usr/"}}
+	tr.addImage("app", "a", base, []testFile{{Name: "etc/foo", Body: "rewritten by A"}})
+	tr.addImage("app", "b", base, []testFile{{Name: "usr/bar", Body: "added by B"}})
+
+	diff, err := tr.client().CompareImages(context.Background(), "app", "a", "b", "", true)
+	if err != nil {
+		t.Fatalf("CompareImages: %v", err)
+	}
+
+	if len(diff.SharedLayers) != 1 || len(diff.AddedLayers) != 1 || len(diff.RemovedLayers) != 1 {
+		t.Errorf("layers: shared %d, added %d, removed %d; want 1 each",
+			len(diff.SharedLayers), len(diff.AddedLayers), len(diff.RemovedLayers))
+	}
+
+	// /etc/foo из A в b остается в версии основы, /usr/bar есть только в b;
+	// /etc и /usr приходят из общей основы и не меняются
+	want := map[string]string{"/etc/foo": ChangeModified, "/usr/bar": ChangeAdded}
+	if len(diff.Files) != len(want) {
+		t.Errorf("files = %+v, want %v", diff.Files, want)
+	}
+	for _, file := range diff.Files {
+		if want[file.Path] != file.Change {
+			t.Errorf("%s: change %q, want %q", file.Path, file.Change, want[file.Path])
+		}
+	}
+}
+
+func TestCompareImagesFilesWhiteout(t *testing.T) {
+	tr := newTestRegistry(t)
+	base := []testFile{{Name: "etc/"}, {Name: "etc/foo", Body: "base"}, {Name: "etc/bar", Body: "bar"}}
+	tr.addImage("app", "v1", base)
+	tr.addImage("app", "v2", base, []testFile{{Name: "etc/.wh.foo"}})
+
+	diff, err := tr.client().CompareImages(context.Background(), "app", "v1", "v2", "", true)
+	if err != nil {
+		t.Fatalf("CompareImages: %v", err)
+	}
+
+	if len(diff.Files) != 1 || diff.Files[0].Path != "/etc/foo" || diff.Files[0].Change != ChangeRemoved {
+		t.Fatalf("files = %+v, want only /etc/foo removed", diff.Files)
+	}
+	if diff.Files[0].From == nil || diff.Files[0].From.Size != int64(len("base")) {
+		t.Errorf("removed file metadata = %+v, want entry from the base layer", diff.Files[0].From)
+	}
+}
@@ -0,0 +1,150 @@
+package registry
+
+import (
+	"context"
+	"regexp"
+	"strings"
+)
+
+// HistoryEntry шаг сборки образа, сопоставленный со слоем манифеста
+type HistoryEntry struct {
+	Created     string `json:"created,omitempty"`
+	CreatedBy   string `json:"createdBy"`
+	Instruction string `json:"instruction"` // Инструкция Dockerfile, восстановленная из created_by
+	Author      string `json:"author,omitempty"`
+	Comment     string `json:"comment,omitempty"`
+	EmptyLayer  bool   `json:"emptyLayer"`
+	LayerDigest string `json:"layerDigest,omitempty"`
+	LayerSize   int64  `json:"layerSize,omitempty"`
+	DiffID      string `json:"diffId,omitempty"`
+}
+
+// ImageHistory история сборки образа и восстановленный Dockerfile
+type ImageHistory struct {
+	Digest     string         `json:"digest"` // Digest манифеста платформы
+	Entries    []HistoryEntry `json:"entries"`
+	Dockerfile string         `json:"dockerfile"`
+	TotalSize  int64          `json:"totalSize"`
+	// Количество непустых шагов истории не совпало с количеством слоев
+	LayersMismatch bool `json:"layersMismatch,omitempty"`
+}
+
+type historyItem struct {
+	Created    string `json:"created"`
+	CreatedBy  string `json:"created_by"`
+	Author     string `json:"author"`
+	Comment    string `json:"comment"`
+	EmptyLayer bool   `json:"empty_layer"`
+}
+
+var (
+	// Префикс аргументов сборки в legacy builder: |2 FOO=1 BAR=2 /bin/sh -c ...
+	buildArgsPrefix = regexp.MustCompile(`^\|\d+\s+(?:\S+=\S*\s+)*`)
+	// ADD/COPY в legacy builder: ADD file:abc in /dst
+	addCopyInPattern = regexp.MustCompile(`^(ADD|COPY)\s+(.+?)\s+in\s+(.+)$`)
+)
+
+// GetImageHistory возвращает историю сборки образа со слоями и восстановленный Dockerfile
+func (c *Client) GetImageHistory(ctx context.Context, repository, reference, platform string) (*ImageHistory, error) {
+	manifest, raw, err := c.resolveImageManifest(ctx, repository, reference, platform)
+	if err != nil {
+		return nil, err
+	}
+
+	configBlob, err := c.fetchImageConfigBlob(ctx, repository, manifest)
+	if err != nil {
+		return nil, err
+	}
+
+	history := &ImageHistory{
+		Digest:  raw.Digest,
+		Entries: make([]HistoryEntry, 0, len(configBlob.History)),
+	}
+
+	layerIndex := 0
+	var instructions []string
+	for _, item := range configBlob.History {
+		entry := HistoryEntry{
+			Created:     item.Created,
+			CreatedBy:   item.CreatedBy,
+			Instruction: reconstructInstruction(item.CreatedBy),
+			Author:      item.Author,
+			Comment:     item.Comment,
+			EmptyLayer:  item.EmptyLayer,
+		}
+
+		// Непустые шаги истории по порядку соответствуют слоям манифеста и diff_ids
+		if !item.EmptyLayer {
+			if layerIndex < len(manifest.Layers) {
+				entry.LayerDigest = manifest.Layers[layerIndex].Digest
+				entry.LayerSize = manifest.Layers[layerIndex].Size
+				history.TotalSize += entry.LayerSize
+			}
+			if layerIndex < len(configBlob.RootFS.DiffIDs) {
+				entry.DiffID = configBlob.RootFS.DiffIDs[layerIndex]
+			}
+			layerIndex++
+		}
+
+		history.Entries = append(history.Entries, entry)
+		if entry.Instruction != "" {
+			instructions = append(instructions, entry.Instruction)
+		}
+	}
+
+	history.LayersMismatch = len(configBlob.History) > 0 && layerIndex != len(manifest.Layers)
+	history.Dockerfile = strings.Join(instructions, "\n")
+
+	return history, nil
+}
+
+// reconstructInstruction восстанавливает инструкцию Dockerfile из поля created_by
+func reconstructInstruction(createdBy string) string {
+	instruction := strings.TrimSpace(createdBy)
+	if instruction == "" {
+		return ""
+	}
+
+	// BuildKit добавляет комментарий "# buildkit"
+	instruction = strings.TrimSpace(strings.TrimSuffix(instruction, "# buildkit"))
+
+	// BuildKit: RUN /bin/sh -c <cmd>
+	if rest, ok := strings.CutPrefix(instruction, "RUN "); ok {
+		return "RUN " + stripShell(rest)
+	}
+
+	instruction = buildArgsPrefix.ReplaceAllString(instruction, "")
+
+	// Legacy builder: метаданные помечаются #(nop)
+	if rest, ok := cutShellPrefix(instruction); ok {
+		if nop, ok := strings.CutPrefix(strings.TrimSpace(rest), "#(nop)"); ok {
+			instruction = strings.TrimSpace(nop)
+		} else {
+			return "RUN " + strings.TrimSpace(rest)
+		}
+	}
+
+	if match := addCopyInPattern.FindStringSubmatch(instruction); match != nil {
+		return match[1] + " " + match[2] + " " + strings.TrimSpace(match[3])
+	}
+
+	return instruction
+}
+
+// stripShell убирает префикс /bin/sh -c из команды RUN
+func stripShell(command string) string {
+	command = buildArgsPrefix.ReplaceAllString(strings.TrimSpace(command), "")
+	if rest, ok := cutShellPrefix(command); ok {
+		return strings.TrimSpace(rest)
+	}
+	return command
+}
+
+func cutShellPrefix(command string) (string, bool) {
+	for _, shell := range []string{"/bin/sh -c ", "/bin/bash -c ", "cmd /S /C "} {
+		if rest, ok := strings.CutPrefix(command, shell); ok {
+			return rest, true
+		}
+	}
+	return command, false
+}
@@ -0,0 +1,145 @@
+package registry
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/reglite/reglite/internal/config"
+)
+
+// Размер страницы Docker Hub API (максимум, который принимает Hub)
+const hubPageSize = 100
+
+// Пространство имен официальных образов Docker Hub
+const hubOfficialNamespace = "library"
+
+// hubRepositoriesPage страница списка репозиториев Docker Hub API
+type hubRepositoriesPage struct {
+	Next    string `json:"next"`
+	Results []struct {
+		Name      string `json:"name"`
+		Namespace string `json:"namespace"`
+	} `json:"results"`
+}
+
+// repositoryName приводит имя репозитория к виду, который ожидает реестр:
+// официальные образы Docker Hub без пространства имен живут в library/
+func (c *Client) repositoryName(repository string) string {
+	if c.registry.IsDockerHub() && !strings.Contains(repository, "/") {
+		return hubOfficialNamespace + "/" + repository
+	}
+	return repository
+}
+
+// hubAPIURL возвращает адрес Docker Hub API
+func (c *Client) hubAPIURL() string {
+	if c.registry.HubAPIURL != "" {
+		return strings.TrimSuffix(c.registry.HubAPIURL, "/")
+	}
+	return config.DefaultHubAPIURL
+}
+
+// hubNamespaces возвращает пространства имен для списка репозиториев:
+// hub_namespaces, иначе пользователь из учетных данных, иначе официальные образы
+func (c *Client) hubNamespaces() []string {
+	if len(c.registry.HubNamespaces) > 0 {
+		return c.registry.HubNamespaces
+	}
+	if username, _, err := c.registry.GetCredentials(); err == nil && username != "" {
+		return []string{username}
+	}
+	return []string{hubOfficialNamespace}
+}
+
+// getHubCatalog собирает репозитории пространств имен через Docker Hub API,
+// так как Docker Hub не поддерживает /v2/_catalog
+func (c *Client) getHubCatalog(ctx context.Context) (*CatalogResponse, error) {
+	token, err := c.hubLogin(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	catalog := &CatalogResponse{Repositories: []string{}}
+	for _, namespace := range c.hubNamespaces() {
+		pageURL := fmt.Sprintf("%s/v2/namespaces/%s/repositories?page_size=%d", c.hubAPIURL(), url.PathEscape(namespace), hubPageSize)
+
+		for i := 0; pageURL != "" && i < maxPages; i++ {
+			var page hubRepositoriesPage
+			if err := c.hubRequest(ctx, http.MethodGet, pageURL, token, nil, &page); err != nil {
+				return nil, fmt.Errorf("failed to list Docker Hub namespace %s: %w", namespace, err)
+			}
+			for _, repository := range page.Results {
+				catalog.Repositories = append(catalog.Repositories, repository.Namespace+"/"+repository.Name)
+			}
+			pageURL = page.Next
+		}
+	}
+
+	sort.Strings(catalog.Repositories)
+	return catalog, nil
+}
+
+// hubLogin получает JWT Docker Hub API по логину и паролю (или access token).
+// Без учетных данных доступны только публичные репозитории
+func (c *Client) hubLogin(ctx context.Context) (string, error) {
+	username, password, err := c.registry.GetCredentials()
+	if err != nil {
+		return "", fmt.Errorf("failed to get credentials: %w", err)
+	}
+	if username == "" || password == "" {
+		return "", nil
+	}
+
+	body, err := json.Marshal(map[string]string{"username": username, "password": password})
+	if err != nil {
+		return "", err
+	}
+
+	var login struct {
+		Token string `json:"token"`
+	}
+	if err := c.hubRequest(ctx, http.MethodPost, c.hubAPIURL()+"/v2/users/login", "", body, &login); err != nil {
+		return "", fmt.Errorf("failed to log in to Docker Hub: %w", err)
+	}
+
+	return login.Token, nil
+}
+
+// hubRequest выполняет запрос к Docker Hub API и декодирует JSON ответ в v
+func (c *Client) hubRequest(ctx context.Context, method, requestURL, token string, body []byte, v interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, requestURL, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := c.do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return &StatusError{StatusCode: resp.StatusCode}
+	}
+
+	return json.NewDecoder(resp.Body).Decode(v)
+}
@@ -0,0 +1,184 @@
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/reglite/reglite/internal/config"
+)
+
+// hubAPI имитирует Docker Hub API: логин и постраничный список репозиториев
+type hubAPI struct {
+	server *httptest.Server
+
+	mu         sync.Mutex
+	logins     int
+	namespaces []string
+	authorized []bool
+}
+
+func newHubAPI(t *testing.T, repositories map[string][]string) *hubAPI {
+	hub := &hubAPI{}
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("POST /v2/users/login", func(w http.ResponseWriter, r *http.Request) {
+		var credentials struct {
+			Username string `json:"username"`
+			Password string `json:"password"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&credentials); err != nil ||
+			credentials.Username != "hubuser" || credentials.Password != "hubpass" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		hub.mu.Lock()
+		hub.logins++
+		hub.mu.Unlock()
+		_ = json.NewEncoder(w).Encode(map[string]string{"token": "hub-jwt"})
+	})
+
+	mux.HandleFunc("GET /v2/namespaces/{namespace}/repositories", func(w http.ResponseWriter, r *http.Request) {
+		namespace := r.PathValue("namespace")
+
+		hub.mu.Lock()
+		hub.namespaces = append(hub.namespaces, namespace)
+		hub.authorized = append(hub.authorized, r.Header.Get("Authorization") == "Bearer hub-jwt")
+		hub.mu.Unlock()
+
+		// Страницы по два репозитория, следующая передается в next
+		names := repositories[namespace]
+		page := 1
+		fmt.Sscan(r.URL.Query().Get("page"), &page)
+		start := min((page-1)*2, len(names))
+		end := min(start+2, len(names))
+
+		response := map[string]any{"next": nil}
+		if end < len(names) {
+			response["next"] = fmt.Sprintf("%s/v2/namespaces/%s/repositories?page=%d&page_size=2", hub.server.URL, namespace, page+1)
+		}
+		results := []map[string]string{}
+		for _, name := range names[start:end] {
+			results = append(results, map[string]string{"name": name, "namespace": namespace})
+		}
+		response["results"] = results
+		_ = json.NewEncoder(w).Encode(response)
+	})
+
+	hub.server = httptest.NewServer(mux)
+	t.Cleanup(hub.server.Close)
+	return hub
+}
+
+func (hub *hubAPI) client(registry config.Registry) *Client {
+	registry.URL = hub.server.URL
+	registry.HubAPIURL = hub.server.URL
+	return NewClient(registry)
+}
+
+func TestHubCatalogWithLogin(t *testing.T) {
+	hub := newHubAPI(t, map[string][]string{
+		"myorg":  {"web", "api", "worker", "cron", "db"},
+		"shared": {"base"},
+	})
+	client := hub.client(config.Registry{
+		Username:      "hubuser",
+		Password:      "hubpass",
+		HubNamespaces: []string{"myorg", "shared"},
+	})
+
+	catalog, err := client.GetCatalog(context.Background())
+	if err != nil {
+		t.Fatalf("GetCatalog: %v", err)
+	}
+
+	want := []string{"myorg/api", "myorg/cron", "myorg/db", "myorg/web", "myorg/worker", "shared/base"}
+	if !slices.Equal(catalog.Repositories, want) {
+		t.Errorf("repositories = %v, want %v", catalog.Repositories, want)
+	}
+
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+	if hub.logins != 1 {
+		t.Errorf("logins = %d, want 1", hub.logins)
+	}
+	// Три страницы myorg по ссылке next и одна shared
+	if got := strings.Join(hub.namespaces, ","); got != "myorg,myorg,myorg,shared" {
+		t.Errorf("requested namespaces = %s", got)
+	}
+	for i, authorized := range hub.authorized {
+		if !authorized {
+			t.Errorf("request %d without Hub token", i)
+		}
+	}
+}
+
+func TestHubCatalogNamespaceFromUsername(t *testing.T) {
+	hub := newHubAPI(t, map[string][]string{"hubuser": {"private"}})
+	client := hub.client(config.Registry{Username: "hubuser", Password: "hubpass"})
+
+	catalog, err := client.GetCatalog(context.Background())
+	if err != nil {
+		t.Fatalf("GetCatalog: %v", err)
+	}
+	if !slices.Equal(catalog.Repositories, []string{"hubuser/private"}) {
+		t.Errorf("repositories = %v", catalog.Repositories)
+	}
+}
+
+func TestHubCatalogAnonymous(t *testing.T) {
+	hub := newHubAPI(t, map[string][]string{"library": {"nginx", "alpine"}})
+	client := hub.client(config.Registry{})
+
+	catalog, err := client.GetCatalog(context.Background())
+	if err != nil {
+		t.Fatalf("GetCatalog: %v", err)
+	}
+	if !slices.Equal(catalog.Repositories, []string{"library/alpine", "library/nginx"}) {
+		t.Errorf("repositories = %v", catalog.Repositories)
+	}
+
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+	if hub.logins != 0 || slices.Contains(hub.authorized, true) {
+		t.Errorf("anonymous catalog used login: logins = %d", hub.logins)
+	}
+}
+
+func TestHubLoginFailure(t *testing.T) {
+	hub := newHubAPI(t, nil)
+	client := hub.client(config.Registry{Username: "hubuser", Password: "wrong"})
+
+	if _, err := client.GetCatalog(context.Background()); err == nil {
+		t.Fatal("expected login error")
+	}
+}
+
+func TestRepositoryName(t *testing.T) {
+	hub := NewClient(config.Registry{URL: "https://" + config.DockerHubHost})
+	private := NewClient(config.Registry{URL: "https://registry.example.com"})
+
+	tests := []struct {
+		client     *Client
+		repository string
+		want       string
+	}{
+		{hub, "nginx", "library/nginx"},
+		{hub, "library/nginx", "library/nginx"},
+		{hub, "myorg/app", "myorg/app"},
+		{private, "nginx", "nginx"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.client.repositoryName(tt.repository); got != tt.want {
+			t.Errorf("repositoryName(%q) on %s = %q, want %q", tt.repository, tt.client.registry.URL, got, tt.want)
+		}
+	}
+}
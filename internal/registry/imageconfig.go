@@ -0,0 +1,182 @@
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// ImageConfig конфигурация образа из config blob
+type ImageConfig struct {
+	Digest       string            `json:"digest"`
+	MediaType    string            `json:"mediaType"`
+	Created      string            `json:"created,omitempty"`
+	Author       string            `json:"author,omitempty"`
+	Architecture string            `json:"architecture,omitempty"`
+	OS           string            `json:"os,omitempty"`
+	Variant      string            `json:"variant,omitempty"`
+	Env          []string          `json:"env"`
+	Entrypoint   []string          `json:"entrypoint"`
+	Cmd          []string          `json:"cmd"`
+	WorkingDir   string            `json:"workingDir,omitempty"`
+	User         string            `json:"user,omitempty"`
+	ExposedPorts []string          `json:"exposedPorts"`
+	Volumes      []string          `json:"volumes"`
+	Labels       map[string]string `json:"labels"`
+	StopSignal   string            `json:"stopSignal,omitempty"`
+	DiffIDs      []string          `json:"diffIds"` // rootfs.diff_ids, digest несжатых слоев
+}
+
+// imageConfigBlob структура config blob по спецификации образа Docker/OCI
+type imageConfigBlob struct {
+	Created      string          `json:"created"`
+	Author       string          `json:"author"`
+	Architecture string          `json:"architecture"`
+	OS           string          `json:"os"`
+	Variant      string          `json:"variant"`
+	Config       containerConfig `json:"config"`
+	RootFS       struct {
+		Type    string   `json:"type"`
+		DiffIDs []string `json:"diff_ids"`
+	} `json:"rootfs"`
+	History []historyItem `json:"history"`
+}
+
+type containerConfig struct {
+	Env          []string            `json:"Env"`
+	Entrypoint   []string            `json:"Entrypoint"`
+	Cmd          []string            `json:"Cmd"`
+	WorkingDir   string              `json:"WorkingDir"`
+	User         string              `json:"User"`
+	ExposedPorts map[string]struct{} `json:"ExposedPorts"`
+	Volumes      map[string]struct{} `json:"Volumes"`
+	Labels       map[string]string   `json:"Labels"`
+	StopSignal   string              `json:"StopSignal"`
+}
+
+// resolveImageManifest получает манифест образа; для multi-arch образов выбирает платформу
+// (по умолчанию первую, не являющуюся attestation)
+func (c *Client) resolveImageManifest(ctx context.Context, repository, reference, platform string) (*imageManifest, *rawManifest, error) {
+	raw, err := c.fetchManifest(ctx, repository, reference)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if isIndexMediaType(raw.MediaType) {
+		var index imageIndex
+		if err := json.Unmarshal(raw.Body, &index); err != nil {
+			return nil, nil, fmt.Errorf("failed to parse image index: %w", err)
+		}
+
+		var selected *Descriptor
+		for i, descriptor := range index.Manifests {
+			if descriptor.Platform == nil || descriptor.Platform.OS == "unknown" {
+				continue
+			}
+			if platform == "" || platformMatches(platform, *descriptor.Platform) {
+				selected = &index.Manifests[i]
+				break
+			}
+		}
+		if selected == nil {
+			if platform != "" {
+				return nil, nil, fmt.Errorf("platform %s not found in %s:%s", platform, repository, reference)
+			}
+			return nil, nil, fmt.Errorf("image index %s:%s has no image manifests", repository, reference)
+		}
+
+		raw, err = c.fetchManifest(ctx, repository, selected.Digest)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	var manifest imageManifest
+	if err := json.Unmarshal(raw.Body, &manifest); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse manifest: %w", err)
+	}
+	if manifest.Config.Digest == "" {
+		return nil, nil, fmt.Errorf("manifest %s:%s has no config (schema %d)", repository, reference, manifest.SchemaVersion)
+	}
+
+	return &manifest, raw, nil
+}
+
+// fetchImageConfigBlob получает и разбирает config blob образа
+func (c *Client) fetchImageConfigBlob(ctx context.Context, repository string, manifest *imageManifest) (*imageConfigBlob, error) {
+	if !isImageConfigMediaType(manifest.Config.MediaType) {
+		return nil, fmt.Errorf("config %s is not an image config", manifest.Config.MediaType)
+	}
+
+	blob, err := c.getBlob(ctx, repository, manifest.Config.Digest)
+	if err != nil {
+		return nil, err
+	}
+
+	var configBlob imageConfigBlob
+	if err := json.Unmarshal(blob, &configBlob); err != nil {
+		return nil, fmt.Errorf("failed to parse image config: %w", err)
+	}
+
+	return &configBlob, nil
+}
+
+// GetImageConfig возвращает конфигурацию образа (Env, Entrypoint, Cmd, Labels и т.д.)
+func (c *Client) GetImageConfig(ctx context.Context, repository, reference, platform string) (*ImageConfig, error) {
+	manifest, _, err := c.resolveImageManifest(ctx, repository, reference, platform)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.imageConfigFromManifest(ctx, repository, manifest)
+}
+
+// imageConfigFromManifest получает конфигурацию образа по уже полученному манифесту
+func (c *Client) imageConfigFromManifest(ctx context.Context, repository string, manifest *imageManifest) (*ImageConfig, error) {
+	configBlob, err := c.fetchImageConfigBlob(ctx, repository, manifest)
+	if err != nil {
+		return nil, err
+	}
+
+	imageConfig := &ImageConfig{
+		Digest:       manifest.Config.Digest,
+		MediaType:    manifest.Config.MediaType,
+		Created:      configBlob.Created,
+		Author:       configBlob.Author,
+		Architecture: configBlob.Architecture,
+		OS:           configBlob.OS,
+		Variant:      configBlob.Variant,
+		Env:          nonNilStrings(configBlob.Config.Env),
+		Entrypoint:   nonNilStrings(configBlob.Config.Entrypoint),
+		Cmd:          nonNilStrings(configBlob.Config.Cmd),
+		WorkingDir:   configBlob.Config.WorkingDir,
+		User:         configBlob.Config.User,
+		ExposedPorts: sortedKeys(configBlob.Config.ExposedPorts),
+		Volumes:      sortedKeys(configBlob.Config.Volumes),
+		Labels:       configBlob.Config.Labels,
+		StopSignal:   configBlob.Config.StopSignal,
+		DiffIDs:      nonNilStrings(configBlob.RootFS.DiffIDs),
+	}
+	if imageConfig.Labels == nil {
+		imageConfig.Labels = map[string]string{}
+	}
+
+	return imageConfig, nil
+}
+
+func sortedKeys(set map[string]struct{}) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func nonNilStrings(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
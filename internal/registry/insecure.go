@@ -0,0 +1,61 @@
+package registry
+
+import (
+	"io"
+	"net/http"
+	"strings"
+)
+
+// baseURL возвращает адрес реестра; после отката insecure реестра на HTTP — с http://
+func (c *Client) baseURL() string {
+	base := strings.TrimSuffix(c.registry.URL, "/")
+	if c.plainHTTP.Load() {
+		return "http://" + strings.TrimPrefix(base, "https://")
+	}
+	return base
+}
+
+// fallbackToHTTP повторяет по HTTP запрос к insecure реестру, который не удалось
+// выполнить по HTTPS. Клиент переходит на HTTP только если по этому адресу отвечает
+// реестр: HTTPS сервер на HTTP порту тоже отвечает, но обычно 400 или редиректом
+func (c *Client) fallbackToHTTP(req *http.Request) (*http.Request, *http.Response, bool) {
+	if !c.registry.Insecure || req.URL.Scheme != "https" || req.Context().Err() != nil ||
+		req.URL.Host != registryHost(c.registry.URL) {
+		return nil, nil, false
+	}
+
+	if !c.plainHTTP.Load() && !c.probePlainHTTP(req) {
+		return nil, nil, false
+	}
+	c.plainHTTP.Store(true)
+
+	plainReq := req.Clone(req.Context())
+	plainReq.URL.Scheme = "http"
+
+	resp, err := c.client.Do(plainReq)
+	if err != nil {
+		return nil, nil, false
+	}
+	return plainReq, resp, true
+}
+
+// probePlainHTTP проверяет, что по HTTP на хосте запроса отвечает Registry API:
+// /v2/ возвращает 200 или 401 без редиректа на HTTPS
+func (c *Client) probePlainHTTP(req *http.Request) bool {
+	probe, err := http.NewRequestWithContext(req.Context(), http.MethodGet, "http://"+req.URL.Host+"/v2/", nil)
+	if err != nil {
+		return false
+	}
+
+	resp, err := c.client.Do(probe)
+	if err != nil {
+		return false
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+
+	if resp.Request.URL.Scheme != "http" {
+		return false
+	}
+	return resp.StatusCode == http.StatusOK || resp.StatusCode == http.StatusUnauthorized
+}
@@ -0,0 +1,88 @@
+package registry
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/reglite/reglite/internal/config"
+)
+
+// insecureClient обращается к серверу по https://, как к реестру из файла учетных данных
+func insecureClient(server *httptest.Server) *Client {
+	return NewClient(config.Registry{
+		URL:      strings.Replace(server.URL, "http://", "https://", 1),
+		Insecure: true,
+		Retry:    config.RetryPolicy{MaxRetries: -1},
+	})
+}
+
+func TestFallbackToHTTPRegistry(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Docker-Distribution-API-Version", "registry/2.0")
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client := insecureClient(server)
+	if err := client.Ping(context.Background()); err != nil {
+		t.Fatalf("Ping: %v", err)
+	}
+	if !client.plainHTTP.Load() {
+		t.Fatal("client did not switch to HTTP")
+	}
+	if !strings.HasPrefix(client.baseURL(), "http://") {
+		t.Errorf("baseURL = %q, want http://", client.baseURL())
+	}
+}
+
+func TestFallbackToHTTPRejectsNonRegistry(t *testing.T) {
+	// Так отвечает HTTPS сервер Go на запрос по HTTP
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "Client sent an HTTP request to an HTTPS server.", http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	client := insecureClient(server)
+	if err := client.Ping(context.Background()); err == nil {
+		t.Fatal("expected HTTPS error")
+	}
+	if client.plainHTTP.Load() {
+		t.Fatal("client switched to HTTP after 400 response")
+	}
+}
+
+func TestFallbackToHTTPRejectsRedirectToHTTPS(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "https://"+r.Host+r.URL.Path, http.StatusMovedPermanently)
+	}))
+	defer server.Close()
+
+	client := insecureClient(server)
+	if err := client.Ping(context.Background()); err == nil {
+		t.Fatal("expected HTTPS error")
+	}
+	if client.plainHTTP.Load() {
+		t.Fatal("client switched to HTTP after redirect to HTTPS")
+	}
+}
+
+func TestNoFallbackForSecureRegistry(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client := NewClient(config.Registry{
+		URL:   strings.Replace(server.URL, "http://", "https://", 1),
+		Retry: config.RetryPolicy{MaxRetries: -1},
+	})
+	if err := client.Ping(context.Background()); err == nil {
+		t.Fatal("expected HTTPS error")
+	}
+	if client.plainHTTP.Load() {
+		t.Fatal("secure registry switched to HTTP")
+	}
+}
@@ -0,0 +1,436 @@
+package registry
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Префиксы whiteout файлов overlay-слоев
+const (
+	whiteoutPrefix = ".wh."
+	whiteoutOpaque = ".wh..wh..opq"
+)
+
+// Максимальное количество переходов по ссылкам при поиске файла
+const maxLinkHops = 16
+
+// ErrStopWalk прерывает обход слоя без ошибки
+var ErrStopWalk = errors.New("stop layer walk")
+
+// ErrFileNotFound файл отсутствует в образе или удален whiteout
+var ErrFileNotFound = errors.New("file not found in image")
+
+var (
+	gzipMagic = []byte{0x1f, 0x8b}
+	zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+)
+
+// LayerEntry запись tar архива слоя
+type LayerEntry struct {
+	Path       string    `json:"path"`
+	Type       string    `json:"type"` // file, dir, symlink, hardlink, char, block, fifo
+	Size       int64     `json:"size"`
+	Mode       string    `json:"mode"`
+	UID        int       `json:"uid"`
+	GID        int       `json:"gid"`
+	ModTime    time.Time `json:"modTime"`
+	LinkTarget string    `json:"linkTarget,omitempty"`
+	Whiteout   bool      `json:"whiteout,omitempty"` // Файл удален в этом слое
+	Opaque     bool      `json:"opaque,omitempty"`   // Содержимое каталога из нижних слоев скрыто
+	Layer      string    `json:"layer,omitempty"`    // Digest слоя, из которого взят файл
+}
+
+// Filesystem объединенная файловая система образа с примененными whiteout
+type Filesystem struct {
+	Digest  string       `json:"digest"` // Digest манифеста платформы
+	Layers  []Descriptor `json:"layers"`
+	Entries []LayerEntry `json:"entries"`
+}
+
+// OpenBlob открывает поток blob по digest; вызывающий обязан закрыть его
+func (c *Client) OpenBlob(ctx context.Context, repository, digest string) (io.ReadCloser, error) {
+	path := fmt.Sprintf("/v2/%s/blobs/%s", c.repositoryName(repository), digest)
+	resp, err := c.makeRequest(ctx, "GET", path)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		_ = resp.Body.Close()
+		return nil, &StatusError{StatusCode: resp.StatusCode}
+	}
+
+	return resp.Body, nil
+}
+
+// WalkLayer проходит по записям слоя, распаковывая gzip/zstd tar потоком;
+// content доступен только внутри fn. Возврат ErrStopWalk из fn завершает обход без ошибки
+func (c *Client) WalkLayer(ctx context.Context, repository, digest string, fn func(entry LayerEntry, content io.Reader) error) error {
+	blob, err := c.OpenBlob(ctx, repository, digest)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = blob.Close() }()
+
+	reader, closeReader, err := decompressLayer(blob)
+	if err != nil {
+		return fmt.Errorf("failed to open layer %s: %w", digest, err)
+	}
+	defer closeReader()
+
+	tarReader := tar.NewReader(reader)
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read layer %s: %w", digest, err)
+		}
+
+		if err := fn(newLayerEntry(header, digest), tarReader); err != nil {
+			if errors.Is(err, ErrStopWalk) {
+				return nil
+			}
+			return err
+		}
+	}
+}
+
+// ListLayerFiles проходит по файлам слоя и передает их в fn по мере чтения
+func (c *Client) ListLayerFiles(ctx context.Context, repository, digest string, fn func(entry LayerEntry) error) error {
+	return c.WalkLayer(ctx, repository, digest, func(entry LayerEntry, _ io.Reader) error {
+		return fn(entry)
+	})
+}
+
+// GetFilesystem строит объединенную файловую систему всех слоев образа
+func (c *Client) GetFilesystem(ctx context.Context, repository, reference, platform string) (*Filesystem, error) {
+	manifest, raw, err := c.resolveImageManifest(ctx, repository, reference, platform)
+	if err != nil {
+		return nil, err
+	}
+
+	files, err := c.mergeLayers(ctx, repository, manifest.Layers, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	fs := &Filesystem{
+		Digest:  raw.Digest,
+		Layers:  manifest.Layers,
+		Entries: make([]LayerEntry, 0, len(files)),
+	}
+	for _, entry := range files {
+		fs.Entries = append(fs.Entries, entry)
+	}
+	sort.Slice(fs.Entries, func(i, j int) bool { return fs.Entries[i].Path < fs.Entries[j].Path })
+
+	return fs, nil
+}
+
+// mergeLayers накладывает слои по порядку и возвращает итоговые файлы по пути.
+// cache (может быть nil) хранит записи уже прочитанных слоев по digest
+func (c *Client) mergeLayers(ctx context.Context, repository string, layers []Descriptor, cache map[string][]LayerEntry) (map[string]LayerEntry, error) {
+	files := make(map[string]LayerEntry)
+	if err := c.applyLayers(ctx, repository, files, layers, cache); err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+// applyLayers накладывает слои по порядку поверх уже собранных files
+func (c *Client) applyLayers(ctx context.Context, repository string, files map[string]LayerEntry, layers []Descriptor, cache map[string][]LayerEntry) error {
+	for _, layer := range layers {
+		layerEntries, err := c.readLayerEntries(ctx, repository, layer.Digest, cache)
+		if err != nil {
+			return err
+		}
+		applyLayer(files, layerEntries)
+	}
+	return nil
+}
+
+// readLayerEntries читает метаданные слоя целиком: opaque whiteout относится только
+// к нижним слоям, а в tar он может идти после файлов этого же слоя
+func (c *Client) readLayerEntries(ctx context.Context, repository, digest string, cache map[string][]LayerEntry) ([]LayerEntry, error) {
+	if layerEntries, cached := cache[digest]; cached {
+		return layerEntries, nil
+	}
+
+	var layerEntries []LayerEntry
+	err := c.ListLayerFiles(ctx, repository, digest, func(entry LayerEntry) error {
+		layerEntries = append(layerEntries, entry)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if cache != nil {
+		cache[digest] = layerEntries
+	}
+	return layerEntries, nil
+}
+
+// GetFile находит файл в верхнем содержащем его слое с учетом whiteout и передает
+// его содержимое в fn потоком. Символические и жесткие ссылки разрешаются
+func (c *Client) GetFile(ctx context.Context, repository, reference, platform, filePath string, fn func(entry LayerEntry, content io.Reader) error) error {
+	manifest, _, err := c.resolveImageManifest(ctx, repository, reference, platform)
+	if err != nil {
+		return err
+	}
+
+	target := normalizeLayerPath(filePath)
+	for hop := 0; hop < maxLinkHops; hop++ {
+		var link, hardlink, hardlinkLayer string
+		found := false
+
+		for i := len(manifest.Layers) - 1; i >= 0 && !found; i-- {
+			hidden := false
+			err := c.WalkLayer(ctx, repository, manifest.Layers[i].Digest, func(entry LayerEntry, content io.Reader) error {
+				switch {
+				case entry.Whiteout || entry.Opaque:
+					// Файлы нижних слоев скрыты, но в этом слое файл еще может быть
+					if entry.Path == target || isParentPath(entry.Path, target) {
+						hidden = hidden || entry.Whiteout || entry.Path != target
+					}
+					return nil
+				case entry.Path != target:
+					if !isParentPath(entry.Path, target) || entry.Type == "dir" {
+						return nil
+					}
+					if entry.Type != "symlink" {
+						// Родительский путь в этом слое является файлом
+						hidden = true
+						return nil
+					}
+					// Каталог-предок заменен символической ссылкой: ищем путь внутри ее цели
+					found = true
+					link = path.Join(resolveLinkTarget(entry.Path, entry.LinkTarget), strings.TrimPrefix(target, entry.Path))
+					return ErrStopWalk
+				}
+
+				found = true
+				switch entry.Type {
+				case "file":
+					if err := fn(entry, content); err != nil {
+						return err
+					}
+				case "symlink":
+					link = resolveLinkTarget(target, entry.LinkTarget)
+				case "hardlink":
+					hardlink = normalizeLayerPath(entry.LinkTarget)
+					hardlinkLayer = manifest.Layers[i].Digest
+				default:
+					return fmt.Errorf("%s is a %s, not a regular file", target, entry.Type)
+				}
+				return ErrStopWalk
+			})
+			if err != nil {
+				return err
+			}
+			if !found && hidden {
+				return fmt.Errorf("%w: %s", ErrFileNotFound, target)
+			}
+		}
+
+		if !found {
+			return fmt.Errorf("%w: %s", ErrFileNotFound, target)
+		}
+		if hardlink != "" {
+			return c.getHardlinkedFile(ctx, repository, hardlinkLayer, target, hardlink, fn)
+		}
+		if link == "" {
+			return nil
+		}
+		target = link
+	}
+
+	return fmt.Errorf("too many links while resolving %s", filePath)
+}
+
+// getHardlinkedFile передает в fn файл, на который указывает жесткая ссылка linkPath.
+// Цель жесткой ссылки — более ранняя запись того же архива слоя, поэтому ищем ее
+// только в этом слое: верхние слои могли заменить путь другим файлом. Запись
+// передается под путем ссылки, размер и права берутся у цели
+func (c *Client) getHardlinkedFile(ctx context.Context, repository, layerDigest, linkPath, target string, fn func(entry LayerEntry, content io.Reader) error) error {
+	for hop := 0; hop < maxLinkHops; hop++ {
+		var next string
+		found := false
+
+		err := c.WalkLayer(ctx, repository, layerDigest, func(entry LayerEntry, content io.Reader) error {
+			if entry.Path != target || entry.Whiteout || entry.Opaque {
+				return nil
+			}
+
+			found = true
+			switch entry.Type {
+			case "file":
+				entry.Path = linkPath
+				if err := fn(entry, content); err != nil {
+					return err
+				}
+			case "hardlink":
+				next = normalizeLayerPath(entry.LinkTarget)
+			default:
+				return fmt.Errorf("hardlink target %s is a %s, not a regular file", target, entry.Type)
+			}
+			return ErrStopWalk
+		})
+		if err != nil {
+			return err
+		}
+		if !found {
+			return fmt.Errorf("%w: hardlink target %s in layer %s", ErrFileNotFound, target, layerDigest)
+		}
+		if next == "" {
+			return nil
+		}
+		target = next
+	}
+
+	return fmt.Errorf("too many links while resolving hardlink %s", target)
+}
+
+// resolveLinkTarget вычисляет путь цели символической ссылки относительно ее каталога
+func resolveLinkTarget(linkPath, linkTarget string) string {
+	if strings.HasPrefix(linkTarget, "/") {
+		return normalizeLayerPath(linkTarget)
+	}
+	return normalizeLayerPath(path.Join(path.Dir(linkPath), linkTarget))
+}
+
+// isParentPath проверяет, что dir является каталогом-предком filePath
+func isParentPath(dir, filePath string) bool {
+	return dir == "/" || strings.HasPrefix(filePath, dir+"/")
+}
+
+// applyLayer накладывает записи слоя на файловую систему нижних слоев
+func applyLayer(files map[string]LayerEntry, layerEntries []LayerEntry) {
+	for _, entry := range layerEntries {
+		switch {
+		case entry.Opaque:
+			removeChildren(files, entry.Path)
+		case entry.Whiteout:
+			delete(files, entry.Path)
+			removeChildren(files, entry.Path)
+		}
+	}
+
+	for _, entry := range layerEntries {
+		if entry.Whiteout || entry.Opaque {
+			continue
+		}
+		if existing, ok := files[entry.Path]; ok && existing.Type == "dir" && entry.Type != "dir" {
+			removeChildren(files, entry.Path)
+		}
+		files[entry.Path] = entry
+	}
+}
+
+// removeChildren удаляет все записи внутри каталога dir
+func removeChildren(files map[string]LayerEntry, dir string) {
+	prefix := dir + "/"
+	if dir == "/" {
+		prefix = "/"
+	}
+	for filePath := range files {
+		if strings.HasPrefix(filePath, prefix) && filePath != dir {
+			delete(files, filePath)
+		}
+	}
+}
+
+// decompressLayer определяет сжатие слоя по сигнатуре и возвращает поток tar
+func decompressLayer(blob io.Reader) (io.Reader, func(), error) {
+	buffered := bufio.NewReader(blob)
+	magic, err := buffered.Peek(4)
+	if err != nil && err != io.EOF {
+		return nil, nil, err
+	}
+
+	switch {
+	case bytes.HasPrefix(magic, gzipMagic):
+		gz, err := gzip.NewReader(buffered)
+		if err != nil {
+			return nil, nil, err
+		}
+		return gz, func() { _ = gz.Close() }, nil
+	case bytes.HasPrefix(magic, zstdMagic):
+		zr, err := zstd.NewReader(buffered)
+		if err != nil {
+			return nil, nil, err
+		}
+		return zr, zr.Close, nil
+	default:
+		return buffered, func() {}, nil
+	}
+}
+
+// newLayerEntry преобразует заголовок tar в запись слоя с учетом whiteout
+func newLayerEntry(header *tar.Header, layer string) LayerEntry {
+	entryPath := normalizeLayerPath(header.Name)
+	dir, name := path.Split(entryPath)
+
+	entry := LayerEntry{
+		Path:       entryPath,
+		Type:       tarEntryType(header.Typeflag),
+		Size:       header.Size,
+		Mode:       header.FileInfo().Mode().String(),
+		UID:        header.Uid,
+		GID:        header.Gid,
+		ModTime:    header.ModTime,
+		LinkTarget: header.Linkname,
+		Layer:      layer,
+	}
+
+	switch {
+	case name == whiteoutOpaque:
+		entry.Path = normalizeLayerPath(dir)
+		entry.Type = "dir"
+		entry.Opaque = true
+	case strings.HasPrefix(name, whiteoutPrefix):
+		entry.Path = normalizeLayerPath(dir + strings.TrimPrefix(name, whiteoutPrefix))
+		entry.Whiteout = true
+	}
+
+	return entry
+}
+
+// normalizeLayerPath приводит путь в архиве к абсолютному виду /a/b
+func normalizeLayerPath(name string) string {
+	return path.Clean("/" + strings.TrimPrefix(name, "./"))
+}
+
+// tarEntryType возвращает тип записи tar в виде строки
+func tarEntryType(typeflag byte) string {
+	switch typeflag {
+	case tar.TypeDir:
+		return "dir"
+	case tar.TypeSymlink:
+		return "symlink"
+	case tar.TypeLink:
+		return "hardlink"
+	case tar.TypeChar:
+		return "char"
+	case tar.TypeBlock:
+		return "block"
+	case tar.TypeFifo:
+		return "fifo"
+	default:
+		return "file"
+	}
+}
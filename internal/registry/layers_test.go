@@ -0,0 +1,37 @@
+package registry
+
+import (
+	"context"
+	"io"
+	"testing"
+)
+
+func TestGetFileHardlinkKeepsRequestedPath(t *testing.T) {
+	tr := newTestRegistry(t)
+	tr.addImage("python", "3", []testFile{
+		{Name: "usr/"},
+		{Name: "usr/bin/"},
+		{Name: "usr/bin/python3.11", Body: "interpreter"},
+		{Name: "usr/bin/python3", Link: "usr/bin/python3.11"},
+	})
+
+	var got LayerEntry
+	var body []byte
+	err := tr.client().GetFile(context.Background(), "python", "3", "", "/usr/bin/python3", func(entry LayerEntry, content io.Reader) error {
+		got = entry
+		var err error
+		body, err = io.ReadAll(content)
+		return err
+	})
+	if err != nil {
+		t.Fatalf("GetFile: %v", err)
+	}
+
+	// Имя файла для скачивания берется из запрошенного пути, содержимое и размер — у цели
+	if got.Path != "/usr/bin/python3" {
+		t.Errorf("entry path = %q, want /usr/bin/python3", got.Path)
+	}
+	if got.Type != "file" || got.Size != int64(len("interpreter")) || string(body) != "interpreter" {
+		t.Errorf("entry = %+v, body %q; want target file contents", got, body)
+	}
+}
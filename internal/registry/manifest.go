@@ -0,0 +1,341 @@
+package registry
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// Media types манифестов Docker и OCI
+const (
+	MediaTypeDockerManifest     = "application/vnd.docker.distribution.manifest.v2+json"
+	MediaTypeDockerManifestList = "application/vnd.docker.distribution.manifest.list.v2+json"
+	MediaTypeDockerManifestV1   = "application/vnd.docker.distribution.manifest.v1+prettyjws"
+	MediaTypeOCIManifest        = "application/vnd.oci.image.manifest.v1+json"
+	MediaTypeOCIIndex           = "application/vnd.oci.image.index.v1+json"
+
+	MediaTypeDockerImageConfig = "application/vnd.docker.container.image.v1+json"
+	MediaTypeOCIImageConfig    = "application/vnd.oci.image.config.v1+json"
+	MediaTypeOCIEmpty          = "application/vnd.oci.empty.v1+json"
+)
+
+// manifestAcceptTypes передаются в Accept, чтобы реестр не конвертировал манифесты
+var manifestAcceptTypes = []string{
+	MediaTypeOCIIndex,
+	MediaTypeDockerManifestList,
+	MediaTypeOCIManifest,
+	MediaTypeDockerManifest,
+	MediaTypeDockerManifestV1,
+}
+
+// Platform описывает платформу образа в manifest list / OCI index
+type Platform struct {
+	OS           string `json:"os"`
+	Architecture string `json:"architecture"`
+	Variant      string `json:"variant,omitempty"`
+	OSVersion    string `json:"os.version,omitempty"`
+}
+
+// String возвращает платформу в формате os/arch[/variant]
+func (p Platform) String() string {
+	parts := []string{p.OS, p.Architecture}
+	if p.Variant != "" {
+		parts = append(parts, p.Variant)
+	}
+	return strings.Join(parts, "/")
+}
+
+// Descriptor ссылка на blob или манифест по digest
+type Descriptor struct {
+	MediaType    string            `json:"mediaType"`
+	Digest       string            `json:"digest"`
+	Size         int64             `json:"size"`
+	ArtifactType string            `json:"artifactType,omitempty"`
+	Platform     *Platform         `json:"platform,omitempty"`
+	Annotations  map[string]string `json:"annotations,omitempty"`
+}
+
+// PlatformManifest манифест одной платформы в multi-arch образе
+type PlatformManifest struct {
+	Platform     Platform `json:"platform"`
+	Digest       string   `json:"digest"`
+	MediaType    string   `json:"mediaType"`
+	ManifestSize int64    `json:"manifestSize"` // Размер самого манифеста
+	Size         int64    `json:"size"`         // Размер образа (config + слои)
+}
+
+type imageIndex struct {
+	SchemaVersion int               `json:"schemaVersion"`
+	MediaType     string            `json:"mediaType"`
+	ArtifactType  string            `json:"artifactType,omitempty"`
+	Manifests     []Descriptor      `json:"manifests"`
+	Annotations   map[string]string `json:"annotations,omitempty"`
+}
+
+// imageManifest манифест образа или OCI артефакта
+type imageManifest struct {
+	SchemaVersion int               `json:"schemaVersion"`
+	MediaType     string            `json:"mediaType"`
+	ArtifactType  string            `json:"artifactType,omitempty"`
+	Config        Descriptor        `json:"config"`
+	Layers        []Descriptor      `json:"layers"`
+	Subject       *Descriptor       `json:"subject,omitempty"`
+	Annotations   map[string]string `json:"annotations,omitempty"`
+}
+
+// rawManifest манифест в исходном виде вместе с метаданными из ответа реестра
+type rawManifest struct {
+	Body      []byte
+	MediaType string
+	Digest    string
+}
+
+// isImageConfigMediaType проверяет, что config blob является конфигурацией образа
+func isImageConfigMediaType(mediaType string) bool {
+	return mediaType == MediaTypeDockerImageConfig || mediaType == MediaTypeOCIImageConfig
+}
+
+// artifactType определяет тип артефакта по правилам OCI 1.1: поле artifactType,
+// иначе media type конфигурации, если это не конфигурация образа
+func (m *imageManifest) artifactType() string {
+	if m.ArtifactType != "" {
+		return m.ArtifactType
+	}
+	if m.Config.MediaType != "" && !isImageConfigMediaType(m.Config.MediaType) && m.Config.MediaType != MediaTypeOCIEmpty {
+		return m.Config.MediaType
+	}
+	return ""
+}
+
+// isIndexMediaType проверяет, является ли манифест списком платформ
+func isIndexMediaType(mediaType string) bool {
+	return mediaType == MediaTypeDockerManifestList || mediaType == MediaTypeOCIIndex
+}
+
+// fetchManifest получает манифест по тегу или digest
+func (c *Client) fetchManifest(ctx context.Context, repository, reference string) (*rawManifest, error) {
+	path := fmt.Sprintf("/v2/%s/manifests/%s", c.repositoryName(repository), reference)
+	resp, err := c.makeRequest(ctx, "GET", path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, &StatusError{StatusCode: resp.StatusCode}
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	return &rawManifest{
+		Body:      body,
+		MediaType: manifestMediaType(resp.Header.Get("Content-Type"), body),
+		Digest:    manifestDigest(resp.Header, body),
+	}, nil
+}
+
+// manifestMediaType определяет media type по Content-Type или полю mediaType в теле
+func manifestMediaType(contentType string, body []byte) string {
+	var probe struct {
+		MediaType string            `json:"mediaType"`
+		Manifests []json.RawMessage `json:"manifests"`
+		Config    json.RawMessage   `json:"config"`
+	}
+	_ = json.Unmarshal(body, &probe)
+
+	if probe.MediaType != "" {
+		return probe.MediaType
+	}
+
+	if mediaType, _, err := mime.ParseMediaType(contentType); err == nil && strings.HasPrefix(mediaType, "application/vnd.") {
+		return mediaType
+	}
+
+	// В OCI поле mediaType необязательно, определяем тип по структуре
+	if probe.Manifests != nil {
+		return MediaTypeOCIIndex
+	}
+	if probe.Config != nil {
+		return MediaTypeOCIManifest
+	}
+
+	return contentType
+}
+
+// manifestDigest получает digest из заголовков или вычисляет его по телу манифеста
+func manifestDigest(header http.Header, body []byte) string {
+	digest := header.Get("Docker-Content-Digest")
+	if digest == "" {
+		digest = header.Get("Docker-Digest")
+	}
+	if digest == "" {
+		digest = header.Get("ETag")
+		if len(digest) > 2 && digest[0] == '"' && digest[len(digest)-1] == '"' {
+			digest = digest[1 : len(digest)-1]
+		}
+	}
+	if !strings.HasPrefix(digest, "sha256:") && !strings.HasPrefix(digest, "sha512:") {
+		sum := sha256.Sum256(body)
+		digest = "sha256:" + hex.EncodeToString(sum[:])
+	}
+
+	return digest
+}
+
+// buildIndexResponse формирует ответ для manifest list / OCI index
+func (c *Client) buildIndexResponse(ctx context.Context, repository, tag string, raw *rawManifest) (*ManifestResponse, error) {
+	var index imageIndex
+	if err := json.Unmarshal(raw.Body, &index); err != nil {
+		return nil, fmt.Errorf("failed to parse image index: %w", err)
+	}
+
+	manifest := &ManifestResponse{
+		MediaType:     raw.MediaType,
+		SchemaVersion: index.SchemaVersion,
+		Tag:           tag,
+		Digest:        raw.Digest,
+		ArtifactType:  index.ArtifactType,
+		Annotations:   index.Annotations,
+		IsIndex:       true,
+		Platforms:     []PlatformManifest{},
+	}
+
+	var architectures []string
+	var firstPlatformBody []byte
+	for _, descriptor := range index.Manifests {
+		if descriptor.Platform == nil || descriptor.Platform.OS == "unknown" {
+			// Attestation манифесты buildx помечаются платформой unknown/unknown
+			continue
+		}
+
+		platformManifest := PlatformManifest{
+			Platform:     *descriptor.Platform,
+			Digest:       descriptor.Digest,
+			MediaType:    descriptor.MediaType,
+			ManifestSize: descriptor.Size,
+		}
+
+		if child, err := c.fetchManifest(ctx, repository, descriptor.Digest); err == nil {
+			var childManifest ManifestResponse
+			extractManifestInfo(child.Body, &childManifest)
+			platformManifest.Size = childManifest.Size
+			if firstPlatformBody == nil {
+				firstPlatformBody = child.Body
+			}
+		}
+
+		manifest.Platforms = append(manifest.Platforms, platformManifest)
+		manifest.Size += platformManifest.Size
+		architectures = append(architectures, descriptor.Platform.String())
+	}
+
+	manifest.Architecture = strings.Join(architectures, ", ")
+
+	// Дату создания берем из конфигурации первой платформы
+	if firstPlatformBody != nil {
+		var platformManifest ManifestResponse
+		c.enrichWithConfigBlob(ctx, repository, firstPlatformBody, &platformManifest)
+		manifest.Created = platformManifest.Created
+	}
+
+	return manifest, nil
+}
+
+// buildImageResponse формирует ответ для манифеста одной платформы
+func (c *Client) buildImageResponse(ctx context.Context, repository, tag string, raw *rawManifest) (*ManifestResponse, error) {
+	var manifest ManifestResponse
+	if err := json.Unmarshal(raw.Body, &manifest); err != nil {
+		return nil, err
+	}
+
+	manifest.MediaType = raw.MediaType
+	manifest.Digest = raw.Digest
+	manifest.Tag = tag
+
+	extractManifestInfo(raw.Body, &manifest)
+
+	var parsed imageManifest
+	if err := json.Unmarshal(raw.Body, &parsed); err == nil && parsed.Config.MediaType != "" {
+		manifest.ArtifactType = parsed.artifactType()
+		manifest.ConfigMediaType = parsed.Config.MediaType
+		manifest.Annotations = parsed.Annotations
+		manifest.Layers = parsed.Layers
+
+		// Конфигурации Helm, WASM, SBOM и других артефактов не разбираем как конфигурацию образа
+		if !isImageConfigMediaType(parsed.Config.MediaType) {
+			return &manifest, nil
+		}
+	}
+
+	c.enrichWithConfigBlob(ctx, repository, raw.Body, &manifest)
+
+	return &manifest, nil
+}
+
+// GetPlatformManifest возвращает манифест конкретной платформы (os/arch[/variant]) multi-arch образа
+func (c *Client) GetPlatformManifest(ctx context.Context, repository, tag, platform string) (*ManifestResponse, error) {
+	raw, err := c.fetchManifest(ctx, repository, tag)
+	if err != nil {
+		return nil, err
+	}
+
+	if !isIndexMediaType(raw.MediaType) {
+		manifest, err := c.buildImageResponse(ctx, repository, tag, raw)
+		if err != nil {
+			return nil, err
+		}
+		if !platformMatches(platform, Platform{OS: manifest.OS, Architecture: manifest.Architecture, Variant: manifest.Variant}) {
+			return nil, fmt.Errorf("image %s:%s is not available for platform %s", repository, tag, platform)
+		}
+		return manifest, nil
+	}
+
+	var index imageIndex
+	if err := json.Unmarshal(raw.Body, &index); err != nil {
+		return nil, fmt.Errorf("failed to parse image index: %w", err)
+	}
+
+	for _, descriptor := range index.Manifests {
+		if descriptor.Platform == nil || !platformMatches(platform, *descriptor.Platform) {
+			continue
+		}
+
+		child, err := c.fetchManifest(ctx, repository, descriptor.Digest)
+		if err != nil {
+			return nil, err
+		}
+
+		manifest, err := c.buildImageResponse(ctx, repository, tag, child)
+		if err != nil {
+			return nil, err
+		}
+		manifest.OS = descriptor.Platform.OS
+		manifest.Architecture = descriptor.Platform.Architecture
+		manifest.Variant = descriptor.Platform.Variant
+
+		return manifest, nil
+	}
+
+	return nil, fmt.Errorf("platform %s not found in %s:%s", platform, repository, tag)
+}
+
+// platformMatches сравнивает платформу с фильтром os/arch[/variant]
+func platformMatches(filter string, platform Platform) bool {
+	parts := strings.Split(filter, "/")
+	if len(parts) < 2 {
+		return false
+	}
+	if parts[0] != platform.OS || parts[1] != platform.Architecture {
+		return false
+	}
+	return len(parts) < 3 || parts[2] == platform.Variant
+}
@@ -0,0 +1,113 @@
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Ограничение на количество страниц, чтобы не зациклиться на некорректном Link
+const maxPages = 10000
+
+// getPage запрашивает одну страницу и возвращает путь следующей из заголовка Link
+func (c *Client) getPage(ctx context.Context, path string, v interface{}) (string, error) {
+	resp, err := c.makeRequest(ctx, "GET", path)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("registry returned status %d", resp.StatusCode)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return "", err
+	}
+
+	return parseNextLink(resp.Header.Values("Link")), nil
+}
+
+// getAllPages проходит по всем страницам, начиная с path
+func getAllPages[T any](ctx context.Context, c *Client, path string, collect func(page *T)) error {
+	seen := make(map[string]bool)
+
+	for i := 0; path != "" && i < maxPages; i++ {
+		if seen[path] {
+			return fmt.Errorf("registry returned pagination loop at %s", path)
+		}
+		seen[path] = true
+
+		page := new(T)
+		next, err := c.getPage(ctx, path, page)
+		if err != nil {
+			return err
+		}
+		collect(page)
+		path = next
+	}
+
+	return nil
+}
+
+// parseNextLink извлекает ссылку rel="next" из заголовков Link (RFC 5988)
+func parseNextLink(headers []string) string {
+	for _, header := range headers {
+		for _, link := range strings.Split(header, ",") {
+			parts := strings.Split(link, ";")
+			target := strings.TrimSpace(parts[0])
+			if !strings.HasPrefix(target, "<") || !strings.HasSuffix(target, ">") {
+				continue
+			}
+
+			for _, param := range parts[1:] {
+				key, value, _ := strings.Cut(strings.TrimSpace(param), "=")
+				if strings.EqualFold(key, "rel") && strings.Trim(value, `"`) == "next" {
+					return linkPath(strings.Trim(target, "<>"))
+				}
+			}
+		}
+	}
+
+	return ""
+}
+
+// linkPath приводит ссылку из Link к пути с query относительно реестра
+func linkPath(target string) string {
+	u, err := url.Parse(target)
+	if err != nil {
+		return ""
+	}
+	return u.RequestURI()
+}
+
+// pagePath добавляет к пути параметры пагинации n и last
+func pagePath(path string, n int, last string) string {
+	query := url.Values{}
+	if n > 0 {
+		query.Set("n", strconv.Itoa(n))
+	}
+	if last != "" {
+		query.Set("last", last)
+	}
+	if len(query) == 0 {
+		return path
+	}
+	return path + "?" + query.Encode()
+}
+
+// pageCursor извлекает курсор (параметр last) из пути следующей страницы
+func pageCursor(next string) string {
+	if next == "" {
+		return ""
+	}
+	u, err := url.Parse(next)
+	if err != nil {
+		return ""
+	}
+	return u.Query().Get("last")
+}
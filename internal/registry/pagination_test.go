@@ -0,0 +1,176 @@
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/reglite/reglite/internal/config"
+)
+
+func TestParseNextLink(t *testing.T) {
+	tests := []struct {
+		name    string
+		headers []string
+		want    string
+	}{
+		{"none", nil, ""},
+		{"relative", []string{`</v2/_catalog?last=b&n=2>; rel="next"`}, "/v2/_catalog?last=b&n=2"},
+		{"absolute", []string{`<https://registry.example.com/v2/app/tags/list?last=v2&n=2>; rel="next"`}, "/v2/app/tags/list?last=v2&n=2"},
+		{"unquoted rel", []string{`</v2/_catalog?last=b>; rel=next`}, "/v2/_catalog?last=b"},
+		{"several links", []string{`</v2/_catalog?last=a>; rel="prev", </v2/_catalog?last=c>; rel="next"`}, "/v2/_catalog?last=c"},
+		{"several headers", []string{`</v2/_catalog>; rel="first"`, `</v2/_catalog?last=c>; REL="next"`}, "/v2/_catalog?last=c"},
+		{"only prev", []string{`</v2/_catalog?last=a>; rel="prev"`}, ""},
+		{"no brackets", []string{`/v2/_catalog?last=a; rel="next"`}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseNextLink(tt.headers); got != tt.want {
+				t.Errorf("parseNextLink(%q) = %q, want %q", tt.headers, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLinkPath(t *testing.T) {
+	tests := []struct {
+		target, want string
+	}{
+		{"/v2/_catalog?n=10&last=app", "/v2/_catalog?n=10&last=app"},
+		{"https://registry.example.com:5000/v2/_catalog?last=app", "/v2/_catalog?last=app"},
+		{"/v2/org/app/tags/list", "/v2/org/app/tags/list"},
+		{"http://[::1:bad", ""},
+	}
+
+	for _, tt := range tests {
+		if got := linkPath(tt.target); got != tt.want {
+			t.Errorf("linkPath(%q) = %q, want %q", tt.target, got, tt.want)
+		}
+	}
+}
+
+func TestPageCursor(t *testing.T) {
+	tests := []struct {
+		next, want string
+	}{
+		{"", ""},
+		{"/v2/_catalog?n=2&last=org%2Fapp", "org/app"},
+		{"/v2/app/tags/list?last=v1.2", "v1.2"},
+		{"/v2/app/tags/list?n=2", ""},
+	}
+
+	for _, tt := range tests {
+		if got := pageCursor(tt.next); got != tt.want {
+			t.Errorf("pageCursor(%q) = %q, want %q", tt.next, got, tt.want)
+		}
+	}
+}
+
+// pagedRegistry отдает каталог и теги страницами по n и last со ссылкой Link на
+// следующую; ссылки каталога абсолютные, ссылки тегов относительные
+func pagedRegistry(t *testing.T, repositories, tags []string) *httptest.Server {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var items []string
+		var key, prefix string
+		switch {
+		case r.URL.Path == "/v2/_catalog":
+			items, key, prefix = repositories, "repositories", server.URL
+		case strings.HasSuffix(r.URL.Path, "/tags/list"):
+			items, key = tags, "tags"
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		n, err := strconv.Atoi(r.URL.Query().Get("n"))
+		if err != nil || n <= 0 {
+			n = 2
+		}
+		start := 0
+		if last := r.URL.Query().Get("last"); last != "" {
+			start, _ = slices.BinarySearch(items, last)
+			start++
+		}
+		end := min(start+n, len(items))
+
+		if end < len(items) {
+			query := url.Values{"n": {strconv.Itoa(n)}, "last": {items[end-1]}}
+			w.Header().Add("Link", fmt.Sprintf(`<%s%s?%s>; rel="next"`, prefix, r.URL.Path, query.Encode()))
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"name": "app", key: items[start:end]})
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestGetCatalogPageCursor(t *testing.T) {
+	server := pagedRegistry(t, []string{"a", "b", "c"}, nil)
+	client := NewClient(config.Registry{URL: server.URL})
+
+	page, err := client.GetCatalogPage(context.Background(), 2, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(page.Repositories, ",") != "a,b" || page.Next != "b" {
+		t.Fatalf("first page = %v, next %q; want a,b and next b", page.Repositories, page.Next)
+	}
+
+	page, err = client.GetCatalogPage(context.Background(), 2, page.Next)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(page.Repositories, ",") != "c" || page.Next != "" {
+		t.Errorf("last page = %v, next %q; want c without next", page.Repositories, page.Next)
+	}
+}
+
+func TestGetTagsPageCursor(t *testing.T) {
+	server := pagedRegistry(t, nil, []string{"v1", "v2", "v3", "v4"})
+	client := NewClient(config.Registry{URL: server.URL})
+
+	page, err := client.GetTagsPage(context.Background(), "app", 3, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(page.Tags, ",") != "v1,v2,v3" || page.Next != "v3" {
+		t.Errorf("page = %v, next %q; want v1..v3 and next v3", page.Tags, page.Next)
+	}
+
+	// Без n реестр выбирает размер страницы сам, все страницы проходит GetTags
+	tags, err := client.GetTags(context.Background(), "app")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(tags.Tags, ",") != "v1,v2,v3,v4" {
+		t.Errorf("GetTags = %v, want all tags", tags.Tags)
+	}
+}
+
+func TestGetAllPagesLoop(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		// Реестр всегда ссылается на одну и ту же страницу
+		w.Header().Set("Link", `</v2/app/tags/list?last=v1>; rel="next"`)
+		_, _ = w.Write([]byte(`{"name":"app","tags":["v1"]}`))
+	}))
+	t.Cleanup(server.Close)
+
+	_, err := NewClient(config.Registry{URL: server.URL}).GetTags(context.Background(), "app")
+	if err == nil || !strings.Contains(err.Error(), "pagination loop") {
+		t.Fatalf("GetTags error = %v, want pagination loop", err)
+	}
+	if got := atomic.LoadInt32(&requests); got != 2 {
+		t.Errorf("requests = %d, want 2 before the repeated page", got)
+	}
+}
@@ -0,0 +1,70 @@
+package registry
+
+import (
+	"context"
+	"sync"
+)
+
+// Количество одновременных запросов к реестру, если max_concurrency не задан
+const defaultMaxConcurrency = 8
+
+// concurrency возвращает ограничение одновременных запросов для реестра
+func (c *Client) concurrency() int {
+	if c.registry.MaxConcurrency > 0 {
+		return c.registry.MaxConcurrency
+	}
+	return defaultMaxConcurrency
+}
+
+// acquire занимает место в семафоре запросов клиента. Клиенты общие для всех обработчиков
+// (Pool), поэтому max_concurrency ограничивает запросы к реестру в целом, а не каждую операцию.
+// Место освобождается, когда получены заголовки ответа; тело читается уже без него
+func (c *Client) acquire(ctx context.Context) error {
+	select {
+	case c.requests <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// release освобождает место, занятое acquire
+func (c *Client) release() {
+	<-c.requests
+}
+
+// runBounded выполняет fn для индексов 0..n-1 не более чем limit горутинами.
+// После отмены ctx новые задачи не запускаются, запущенные дожидаются завершения
+func runBounded(ctx context.Context, n, limit int, fn func(i int)) error {
+	if limit < 1 {
+		limit = 1
+	}
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for worker := 0; worker < min(limit, n); worker++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				fn(i)
+			}
+		}()
+	}
+
+	var err error
+	for i := 0; i < n && err == nil; i++ {
+		select {
+		case jobs <- i:
+		case <-ctx.Done():
+			err = ctx.Err()
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	if err != nil {
+		return err
+	}
+	return ctx.Err()
+}
@@ -0,0 +1,114 @@
+package registry
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/reglite/reglite/internal/config"
+)
+
+// concurrencyServer отдает манифесты и запоминает наибольшее число одновременных запросов.
+// Тег index — multi-arch образ из children вложенных манифестов
+func concurrencyServer(children int, maxActive *int32) *httptest.Server {
+	var active int32
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		current := atomic.AddInt32(&active, 1)
+		defer atomic.AddInt32(&active, -1)
+		for {
+			seen := atomic.LoadInt32(maxActive)
+			if current <= seen || atomic.CompareAndSwapInt32(maxActive, seen, current) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+
+		reference := r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]
+		if reference == "index" {
+			manifests := make([]string, children)
+			for i := range manifests {
+				manifests[i] = fmt.Sprintf(`{"mediaType":%q,"digest":"sha256:child%d","size":1}`, MediaTypeOCIManifest, i)
+			}
+			w.Header().Set("Content-Type", MediaTypeOCIIndex)
+			fmt.Fprintf(w, `{"schemaVersion":2,"mediaType":%q,"manifests":[%s]}`, MediaTypeOCIIndex, strings.Join(manifests, ","))
+			return
+		}
+
+		w.Header().Set("Content-Type", MediaTypeOCIManifest)
+		fmt.Fprintf(w, `{"schemaVersion":2,"mediaType":%q,"config":{"digest":"sha256:config-%s","size":10},"layers":[{"digest":"sha256:layer-%s","size":100}]}`,
+			MediaTypeOCIManifest, reference, reference)
+	}))
+}
+
+func TestMaxConcurrencyIsPerRegistry(t *testing.T) {
+	var maxActive int32
+	server := concurrencyServer(0, &maxActive)
+	defer server.Close()
+
+	client := NewClient(config.Registry{URL: server.URL, MaxConcurrency: 3})
+
+	tags := make([]string, 12)
+	for i := range tags {
+		tags[i] = fmt.Sprintf("v%d", i)
+	}
+
+	// Несколько операций одновременно делят одно ограничение
+	var wg sync.WaitGroup
+	for range 3 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := client.GetRepositoryStorage(context.Background(), "app", tags); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	got := atomic.LoadInt32(&maxActive)
+	if got > 3 {
+		t.Errorf("max concurrent requests = %d, want <= 3", got)
+	}
+	if got < 2 {
+		t.Errorf("max concurrent requests = %d, requests were not parallel", got)
+	}
+}
+
+func TestIndexChildrenFetchedInParallel(t *testing.T) {
+	var maxActive int32
+	server := concurrencyServer(6, &maxActive)
+	defer server.Close()
+
+	client := NewClient(config.Registry{URL: server.URL, MaxConcurrency: 4})
+
+	storage, err := client.GetRepositoryStorage(context.Background(), "app", []string{"index"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(storage.Tags) != 1 || len(storage.Blobs) != 12 {
+		t.Fatalf("unexpected storage: %+v", storage)
+	}
+	if got := atomic.LoadInt32(&maxActive); got < 2 || got > 4 {
+		t.Errorf("max concurrent requests = %d, want 2..4", got)
+	}
+}
+
+func TestAcquireRespectsContext(t *testing.T) {
+	client := NewClient(config.Registry{URL: "http://registry.invalid", MaxConcurrency: 1})
+	if err := client.acquire(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	defer client.release()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := client.acquire(ctx); err == nil {
+		t.Fatal("acquire succeeded while the only slot was taken")
+	}
+}
@@ -0,0 +1,200 @@
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// Типы артефактов cosign для подписей, attestations и SBOM, хранимых в тегах
+const (
+	ArtifactTypeCosignSignature   = "application/vnd.dev.cosign.artifact.sig.v1+json"
+	ArtifactTypeCosignAttestation = "application/vnd.dev.cosign.artifact.att.v1+json"
+	ArtifactTypeCosignSBOM        = "application/vnd.dev.cosign.artifact.sbom.v1+json"
+)
+
+// Тип для артефактов, у которых artifactType не указан
+const unknownArtifactType = "unknown"
+
+// cosignTagSuffixes суффиксы тегов, в которых cosign хранит артефакты без Referrers API
+var cosignTagSuffixes = map[string]string{
+	".sig":  ArtifactTypeCosignSignature,
+	".att":  ArtifactTypeCosignAttestation,
+	".sbom": ArtifactTypeCosignSBOM,
+}
+
+// ReferrersResponse артефакты, прикрепленные к манифесту (подписи, SBOM, attestations)
+type ReferrersResponse struct {
+	Digest    string                  `json:"digest"`
+	Referrers []Descriptor            `json:"referrers"`
+	Groups    map[string][]Descriptor `json:"groups"` // Referrers, сгруппированные по artifactType
+	Source    string                  `json:"source"` // api или tag (схема sha256-<hex>)
+}
+
+// GetReferrers возвращает артефакты, ссылающиеся на digest, через /v2/<name>/referrers/<digest>
+// с fallback на схему тегов sha256-<hex>
+func (c *Client) GetReferrers(ctx context.Context, repository, digest string) (*ReferrersResponse, error) {
+	algorithm, hex, ok := strings.Cut(digest, ":")
+	if !ok || algorithm == "" || hex == "" {
+		return nil, fmt.Errorf("invalid digest %q", digest)
+	}
+
+	result := &ReferrersResponse{
+		Digest:    digest,
+		Referrers: []Descriptor{},
+		Source:    "api",
+	}
+
+	referrers, supported, err := c.fetchReferrersAPI(ctx, repository, digest)
+	if err != nil {
+		return nil, err
+	}
+
+	if supported {
+		result.Referrers = append(result.Referrers, referrers...)
+	} else {
+		result.Source = "tag"
+		referrers, err := c.fetchReferrersTag(ctx, repository, algorithm+"-"+hex)
+		if err != nil {
+			return nil, err
+		}
+		result.Referrers = append(result.Referrers, referrers...)
+	}
+
+	// cosign без OCI 1.1 режима хранит артефакты в тегах sha256-<hex>.sig/.att/.sbom
+	result.Referrers = append(result.Referrers, c.fetchCosignArtifacts(ctx, repository, algorithm+"-"+hex)...)
+
+	result.Groups = groupReferrers(result.Referrers)
+
+	return result, nil
+}
+
+// fetchReferrersAPI запрашивает Referrers API; supported=false, если реестр его не поддерживает
+func (c *Client) fetchReferrersAPI(ctx context.Context, repository, digest string) ([]Descriptor, bool, error) {
+	var referrers []Descriptor
+	path := fmt.Sprintf("/v2/%s/referrers/%s", c.repositoryName(repository), digest)
+
+	for page := 0; path != "" && page < maxPages; page++ {
+		resp, err := c.makeRequest(ctx, "GET", path)
+		if err != nil {
+			return nil, false, err
+		}
+
+		if resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusMethodNotAllowed ||
+			resp.StatusCode == http.StatusBadRequest {
+			_ = resp.Body.Close()
+			if page == 0 {
+				return nil, false, nil
+			}
+			return nil, false, &StatusError{StatusCode: resp.StatusCode}
+		}
+
+		if resp.StatusCode != http.StatusOK {
+			_ = resp.Body.Close()
+			return nil, false, &StatusError{StatusCode: resp.StatusCode}
+		}
+
+		mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+		if page == 0 && mediaType != MediaTypeOCIIndex {
+			// Реестр ответил чем-то кроме индекса (например, HTML), считаем API неподдерживаемым
+			_ = resp.Body.Close()
+			return nil, false, nil
+		}
+
+		var index imageIndex
+		err = json.NewDecoder(resp.Body).Decode(&index)
+		_ = resp.Body.Close()
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to parse referrers index: %w", err)
+		}
+
+		referrers = append(referrers, index.Manifests...)
+		path = parseNextLink(resp.Header.Values("Link"))
+	}
+
+	return referrers, true, nil
+}
+
+// fetchReferrersTag читает индекс referrers из тега по схеме sha256-<hex>
+func (c *Client) fetchReferrersTag(ctx context.Context, repository, tag string) ([]Descriptor, error) {
+	raw, found, err := c.fetchOptionalManifest(ctx, repository, tag)
+	if err != nil || !found {
+		return nil, err
+	}
+
+	var index imageIndex
+	if err := json.Unmarshal(raw.Body, &index); err != nil {
+		return nil, fmt.Errorf("failed to parse referrers tag %s: %w", tag, err)
+	}
+
+	return index.Manifests, nil
+}
+
+// fetchCosignArtifacts ищет артефакты cosign в тегах sha256-<hex>.sig/.att/.sbom
+func (c *Client) fetchCosignArtifacts(ctx context.Context, repository, tagPrefix string) []Descriptor {
+	var artifacts []Descriptor
+
+	suffixes := make([]string, 0, len(cosignTagSuffixes))
+	for suffix := range cosignTagSuffixes {
+		suffixes = append(suffixes, suffix)
+	}
+	sort.Strings(suffixes)
+
+	for _, suffix := range suffixes {
+		raw, found, err := c.fetchOptionalManifest(ctx, repository, tagPrefix+suffix)
+		if err != nil || !found {
+			continue
+		}
+
+		descriptor := Descriptor{
+			MediaType:    raw.MediaType,
+			Digest:       raw.Digest,
+			Size:         int64(len(raw.Body)),
+			ArtifactType: cosignTagSuffixes[suffix],
+			Annotations:  map[string]string{"org.opencontainers.image.ref.name": tagPrefix + suffix},
+		}
+
+		var parsed imageManifest
+		if err := json.Unmarshal(raw.Body, &parsed); err == nil {
+			for key, value := range parsed.Annotations {
+				descriptor.Annotations[key] = value
+			}
+		}
+
+		artifacts = append(artifacts, descriptor)
+	}
+
+	return artifacts
+}
+
+// fetchOptionalManifest получает манифест, возвращая found=false при 404
+func (c *Client) fetchOptionalManifest(ctx context.Context, repository, reference string) (*rawManifest, bool, error) {
+	raw, err := c.fetchManifest(ctx, repository, reference)
+	if err != nil {
+		var statusErr *StatusError
+		if errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusNotFound {
+			return nil, false, nil
+		}
+		return nil, false, err
+	}
+
+	return raw, true, nil
+}
+
+// groupReferrers группирует артефакты по artifactType
+func groupReferrers(referrers []Descriptor) map[string][]Descriptor {
+	groups := make(map[string][]Descriptor)
+	for _, referrer := range referrers {
+		artifactType := referrer.ArtifactType
+		if artifactType == "" {
+			artifactType = unknownArtifactType
+		}
+		groups[artifactType] = append(groups[artifactType], referrer)
+	}
+	return groups
+}
@@ -0,0 +1,191 @@
+package registry
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/reglite/reglite/internal/config"
+)
+
+// testFile запись слоя: имя с / на конце — каталог, Link — жесткая ссылка на другой путь
+type testFile struct {
+	Name string
+	Body string
+	Link string
+}
+
+// testRegistry реестр в памяти: манифесты, blob и теги, ответы с ошибкой по ссылке манифеста
+type testRegistry struct {
+	server *httptest.Server
+
+	mu        sync.Mutex
+	manifests map[string][]byte // repo:tag и repo@digest
+	types     map[string]string
+	blobs     map[string][]byte
+	tags      map[string][]string
+	failures  map[string]int // repo:reference -> HTTP статус ответа
+}
+
+func newTestRegistry(t *testing.T) *testRegistry {
+	tr := &testRegistry{
+		manifests: make(map[string][]byte),
+		types:     make(map[string]string),
+		blobs:     make(map[string][]byte),
+		tags:      make(map[string][]string),
+		failures:  make(map[string]int),
+	}
+	tr.server = httptest.NewServer(http.HandlerFunc(tr.serveHTTP))
+	t.Cleanup(tr.server.Close)
+	return tr
+}
+
+func (tr *testRegistry) client() *Client {
+	return NewClient(config.Registry{URL: tr.server.URL, Retry: config.RetryPolicy{MaxRetries: -1}})
+}
+
+func testDigest(data []byte) string {
+	sum := sha256.Sum256(data)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+func (tr *testRegistry) addBlob(data []byte) string {
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+	digest := testDigest(data)
+	tr.blobs[digest] = data
+	return digest
+}
+
+// addManifest сохраняет манифест по digest и, если задан, по тегу
+func (tr *testRegistry) addManifest(repository, tag, mediaType string, manifest any) string {
+	body, _ := json.Marshal(manifest)
+	digest := testDigest(body)
+
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+	tr.manifests[repository+"@"+digest] = body
+	tr.types[repository+"@"+digest] = mediaType
+	if tag != "" {
+		tr.manifests[repository+":"+tag] = body
+		tr.types[repository+":"+tag] = mediaType
+		tr.tags[repository] = append(tr.tags[repository], tag)
+	}
+	return digest
+}
+
+// addImage собирает образ из слоев tar+gzip и возвращает digest его манифеста
+func (tr *testRegistry) addImage(repository, tag string, layers ...[]testFile) string {
+	descriptors := make([]Descriptor, 0, len(layers))
+	for _, files := range layers {
+		blob := gzipLayer(files)
+		descriptors = append(descriptors, Descriptor{
+			MediaType: "application/vnd.oci.image.layer.v1.tar+gzip",
+			Digest:    tr.addBlob(blob),
+			Size:      int64(len(blob)),
+		})
+	}
+	configBlob := []byte(`{"architecture":"amd64","os":"linux","rootfs":{"type":"layers"}}`)
+
+	return tr.addManifest(repository, tag, MediaTypeOCIManifest, map[string]any{
+		"schemaVersion": 2,
+		"mediaType":     MediaTypeOCIManifest,
+		"config":        Descriptor{MediaType: MediaTypeOCIImageConfig, Digest: tr.addBlob(configBlob), Size: int64(len(configBlob))},
+		"layers":        descriptors,
+	})
+}
+
+// fail заставляет реестр отвечать status на запросы манифеста repository:reference
+func (tr *testRegistry) fail(repository, reference string, status int) {
+	tr.mu.Lock()
+	tr.failures[repository+":"+reference] = status
+	tr.mu.Unlock()
+}
+
+func gzipLayer(files []testFile) []byte {
+	var layer bytes.Buffer
+	gz := gzip.NewWriter(&layer)
+	tw := tar.NewWriter(gz)
+	modTime := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	for _, file := range files {
+		header := &tar.Header{Name: file.Name, Mode: 0644, Size: int64(len(file.Body)), Typeflag: tar.TypeReg, ModTime: modTime}
+		switch {
+		case strings.HasSuffix(file.Name, "/"):
+			header.Typeflag, header.Mode, header.Size = tar.TypeDir, 0755, 0
+		case file.Link != "":
+			header.Typeflag, header.Linkname, header.Size = tar.TypeLink, file.Link, 0
+		}
+		_ = tw.WriteHeader(header)
+		if header.Typeflag == tar.TypeReg {
+			_, _ = tw.Write([]byte(file.Body))
+		}
+	}
+
+	_ = tw.Close()
+	_ = gz.Close()
+	return layer.Bytes()
+}
+
+func (tr *testRegistry) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+
+	path := strings.TrimPrefix(r.URL.Path, "/v2/")
+	switch {
+	case r.URL.Path == "/v2/":
+		w.WriteHeader(http.StatusOK)
+
+	case path == "_catalog":
+		repositories := slices.Sorted(maps.Keys(tr.tags))
+		_ = json.NewEncoder(w).Encode(map[string]any{"repositories": repositories})
+
+	case strings.Contains(path, "/manifests/"):
+		repository, reference, _ := strings.Cut(path, "/manifests/")
+		if status, failed := tr.failures[repository+":"+reference]; failed {
+			w.WriteHeader(status)
+			return
+		}
+		key := repository + ":" + reference
+		if strings.HasPrefix(reference, "sha256:") {
+			key = repository + "@" + reference
+		}
+		body, exists := tr.manifests[key]
+		if !exists {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", tr.types[key])
+		w.Header().Set("Docker-Content-Digest", testDigest(body))
+		if r.Method != http.MethodHead {
+			_, _ = w.Write(body)
+		}
+
+	case strings.Contains(path, "/blobs/"):
+		_, digest, _ := strings.Cut(path, "/blobs/")
+		blob, exists := tr.blobs[digest]
+		if !exists {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write(blob)
+
+	case strings.HasSuffix(path, "/tags/list"):
+		repository := strings.TrimSuffix(path, "/tags/list")
+		_ = json.NewEncoder(w).Encode(map[string]any{"name": repository, "tags": tr.tags[repository]})
+
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
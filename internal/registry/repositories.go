@@ -0,0 +1,88 @@
+package registry
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"path"
+	"sort"
+	"strings"
+)
+
+// isRepositoryPattern проверяет, является ли запись repositories шаблоном path.Match
+func isRepositoryPattern(entry string) bool {
+	return strings.ContainsAny(entry, "*?[")
+}
+
+// configuredCatalog возвращает репозитории из repositories в inventory. Имена
+// используются как есть, без обращения к реестру; шаблоны сопоставляются со списком
+// репозиториев реестра (_catalog или Docker Hub API), поэтому требуют доступа к нему
+func (c *Client) configuredCatalog(ctx context.Context) (*CatalogResponse, error) {
+	seen := make(map[string]bool)
+	var patterns []string
+
+	for _, entry := range c.registry.Repositories {
+		if isRepositoryPattern(entry) {
+			patterns = append(patterns, entry)
+		} else {
+			seen[entry] = true
+		}
+	}
+
+	if len(patterns) > 0 {
+		catalog, err := c.registryCatalog(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list repositories for patterns: %w", err)
+		}
+		for _, repository := range catalog.Repositories {
+			for _, pattern := range patterns {
+				if matched, _ := path.Match(pattern, repository); matched {
+					seen[repository] = true
+					break
+				}
+			}
+		}
+	}
+
+	catalog := &CatalogResponse{Repositories: make([]string, 0, len(seen))}
+	for repository := range seen {
+		catalog.Repositories = append(catalog.Repositories, repository)
+	}
+	sort.Strings(catalog.Repositories)
+
+	return catalog, nil
+}
+
+// pageCatalog возвращает страницу отсортированного списка репозиториев размером n после last
+func pageCatalog(catalog *CatalogResponse, n int, last string) *CatalogResponse {
+	repositories := catalog.Repositories
+	start := sort.SearchStrings(repositories, last)
+	if start < len(repositories) && repositories[start] == last {
+		start++
+	}
+	repositories = repositories[start:]
+
+	page := &CatalogResponse{Repositories: repositories}
+	if n > 0 && len(repositories) > n {
+		page.Repositories = repositories[:n]
+		page.Next = repositories[n-1]
+	}
+
+	return page
+}
+
+// Ping проверяет доступность реестра запросом к /v2/ (с авторизацией, если она нужна).
+// В отличие от _catalog, /v2/ доступен на любом реестре, включая Docker Hub и GHCR
+func (c *Client) Ping(ctx context.Context) error {
+	resp, err := c.makeRequest(ctx, http.MethodGet, "/v2/")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return &StatusError{StatusCode: resp.StatusCode}
+	}
+
+	return nil
+}
@@ -0,0 +1,206 @@
+package registry
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/reglite/reglite/internal/config"
+)
+
+// Параметры повторов по умолчанию, если retry не задан
+const (
+	defaultMaxRetries = 3
+	defaultMinBackoff = 500 * time.Millisecond
+	defaultMaxBackoff = 10 * time.Second
+)
+
+// RateLimit лимит запросов из заголовков ratelimit-* (Docker Hub)
+type RateLimit struct {
+	Limit     int       `json:"limit"`
+	Remaining int       `json:"remaining"`
+	Window    int       `json:"window,omitempty"` // Окно лимита в секундах
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// RateLimit возвращает последний лимит запросов, сообщенный реестром
+func (c *Client) RateLimit() *RateLimit {
+	c.rateLimitMutex.RLock()
+	defer c.rateLimitMutex.RUnlock()
+
+	if c.rateLimit == nil {
+		return nil
+	}
+	limit := *c.rateLimit
+	return &limit
+}
+
+// recordRateLimit запоминает лимит из заголовков ответа, если реестр его передал
+func (c *Client) recordRateLimit(header http.Header) {
+	remaining, window, ok := parseRateLimitHeader(header.Get("RateLimit-Remaining"))
+	if !ok {
+		return
+	}
+	limit, _, _ := parseRateLimitHeader(header.Get("RateLimit-Limit"))
+
+	c.rateLimitMutex.Lock()
+	defer c.rateLimitMutex.Unlock()
+
+	c.rateLimit = &RateLimit{
+		Limit:     limit,
+		Remaining: remaining,
+		Window:    window,
+		UpdatedAt: time.Now(),
+	}
+}
+
+// parseRateLimitHeader разбирает значение вида "76;w=21600"
+func parseRateLimitHeader(value string) (count, window int, ok bool) {
+	parts := strings.Split(value, ";")
+	count, err := strconv.Atoi(strings.TrimSpace(parts[0]))
+	if err != nil {
+		return 0, 0, false
+	}
+
+	for _, param := range parts[1:] {
+		if key, value, _ := strings.Cut(strings.TrimSpace(param), "="); key == "w" {
+			window, _ = strconv.Atoi(value)
+		}
+	}
+
+	return count, window, true
+}
+
+// retryPolicy возвращает параметры повторов реестра с учетом значений по умолчанию
+func (c *Client) retryPolicy() config.RetryPolicy {
+	policy := c.registry.Retry
+	if policy.MaxRetries == 0 {
+		policy.MaxRetries = defaultMaxRetries
+	}
+	if policy.MinBackoff <= 0 {
+		policy.MinBackoff = defaultMinBackoff
+	}
+	if policy.MaxBackoff <= 0 {
+		policy.MaxBackoff = defaultMaxBackoff
+	}
+	return policy
+}
+
+// do выполняет запрос, повторяя идемпотентные запросы при сетевых ошибках, 429 и 5xx.
+// Задержка растет экспоненциально со случайным разбросом; Retry-After больше max_backoff
+// не ждем и возвращаем ответ как есть
+func (c *Client) do(req *http.Request) (*http.Response, error) {
+	if c.setupErr != nil {
+		return nil, c.setupErr
+	}
+
+	policy := c.retryPolicy()
+
+	for attempt := 0; ; attempt++ {
+		resp, err := c.client.Do(req)
+		if err != nil {
+			if plainReq, plainResp, ok := c.fallbackToHTTP(req); ok {
+				req, resp, err = plainReq, plainResp, nil
+			}
+		}
+		if resp != nil {
+			c.recordRateLimit(resp.Header)
+		}
+
+		if attempt >= policy.MaxRetries || !isRetryableMethod(req.Method) || !shouldRetry(req.Context(), resp, err) {
+			return resp, err
+		}
+
+		delay := backoffDelay(policy, attempt)
+		if resp != nil {
+			if retryAfter, ok := parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()); ok {
+				if retryAfter > policy.MaxBackoff {
+					return resp, nil
+				}
+				delay = max(delay, retryAfter)
+			}
+			_ = resp.Body.Close()
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		}
+	}
+}
+
+// isRetryableMethod проверяет, можно ли повторить запрос. DELETE не повторяем:
+// если ответ потерялся после удаления, повтор вернет 404 вместо успеха
+func isRetryableMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	}
+	return false
+}
+
+// shouldRetry проверяет, является ли ошибка или статус ответа временными
+func shouldRetry(ctx context.Context, resp *http.Response, err error) bool {
+	if err != nil {
+		// Ошибки проверки сертификатов и TLS alert от реестра при повторе не исчезнут
+		var verificationErr *tls.CertificateVerificationError
+		var alertErr tls.AlertError
+		if errors.As(err, &verificationErr) || errors.As(err, &alertErr) {
+			return false
+		}
+		return ctx.Err() == nil
+	}
+
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusInternalServerError, http.StatusBadGateway,
+		http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// backoffDelay вычисляет задержку перед повтором: min_backoff * 2^attempt, не больше
+// max_backoff, со случайным разбросом в пределах второй половины интервала.
+// Задержка удваивается до max_backoff, а не сдвигом, чтобы не переполниться
+func backoffDelay(policy config.RetryPolicy, attempt int) time.Duration {
+	delay := policy.MinBackoff
+	for i := 0; i < attempt && delay < policy.MaxBackoff; i++ {
+		if delay > policy.MaxBackoff/2 {
+			delay = policy.MaxBackoff
+			break
+		}
+		delay *= 2
+	}
+	delay = min(delay, policy.MaxBackoff)
+
+	half := delay / 2
+	if half <= 0 {
+		return delay
+	}
+	return half + rand.N(half+1)
+}
+
+// parseRetryAfter разбирает Retry-After в секундах или в формате HTTP-даты
+func parseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(strings.TrimSpace(value)); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	if date, err := http.ParseTime(value); err == nil {
+		return max(date.Sub(now), 0), true
+	}
+
+	return 0, false
+}
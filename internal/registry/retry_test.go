@@ -0,0 +1,193 @@
+package registry
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/reglite/reglite/internal/config"
+)
+
+func TestBackoffDelay(t *testing.T) {
+	policies := []config.RetryPolicy{
+		{MinBackoff: 500 * time.Millisecond, MaxBackoff: 10 * time.Second},
+		{MinBackoff: 10 * time.Second, MaxBackoff: 10 * time.Minute},
+		{MinBackoff: time.Hour, MaxBackoff: 1<<63 - 1},
+		{MinBackoff: time.Nanosecond, MaxBackoff: time.Nanosecond},
+		{MinBackoff: time.Minute, MaxBackoff: time.Second},
+	}
+
+	// Большие номера попыток не должны переполнять задержку
+	for _, policy := range policies {
+		for attempt := 0; attempt < 200; attempt++ {
+			delay := backoffDelay(policy, attempt)
+			if delay < 0 || delay > policy.MaxBackoff {
+				t.Fatalf("backoffDelay(%v, %d) = %v, want 0..%v", policy, attempt, delay, policy.MaxBackoff)
+			}
+		}
+	}
+
+	policy := config.RetryPolicy{MinBackoff: time.Second, MaxBackoff: time.Minute}
+	if delay := backoffDelay(policy, 3); delay < 4*time.Second || delay > 8*time.Second {
+		t.Errorf("backoffDelay(attempt 3) = %v, want 4s..8s", delay)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		value string
+		want  time.Duration
+		ok    bool
+	}{
+		{"", 0, false},
+		{"120", 2 * time.Minute, true},
+		{" 0 ", 0, true},
+		{"Mon, 01 Jan 2024 12:00:30 GMT", 30 * time.Second, true},
+		{"Mon, 01 Jan 2024 11:00:00 GMT", 0, true},
+		{"-5", 0, false},
+		{"soon", 0, false},
+	}
+
+	for _, tt := range tests {
+		got, ok := parseRetryAfter(tt.value, now)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("parseRetryAfter(%q) = %v, %v; want %v, %v", tt.value, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+// statusSequence отвечает статусами по порядку, затем 200; считает запросы
+func statusSequence(t *testing.T, header http.Header, statuses ...int) (*httptest.Server, *int32) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := int(atomic.AddInt32(&requests, 1))
+		for key, values := range header {
+			w.Header()[key] = values
+		}
+		if n <= len(statuses) {
+			w.WriteHeader(statuses[n-1])
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(server.Close)
+	return server, &requests
+}
+
+func retryClient(server *httptest.Server, maxRetries int) *Client {
+	return NewClient(config.Registry{
+		URL:   server.URL,
+		Retry: config.RetryPolicy{MaxRetries: maxRetries, MinBackoff: time.Millisecond, MaxBackoff: 50 * time.Millisecond},
+	})
+}
+
+func doRequest(t *testing.T, client *Client, method string) *http.Response {
+	t.Helper()
+	req, err := http.NewRequestWithContext(context.Background(), method, client.registry.URL+"/v2/", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := client.do(req)
+	if err != nil {
+		t.Fatalf("do: %v", err)
+	}
+	_ = resp.Body.Close()
+	return resp
+}
+
+func TestDoRetriesTemporaryErrors(t *testing.T) {
+	server, requests := statusSequence(t, nil, http.StatusServiceUnavailable, http.StatusBadGateway)
+
+	resp := doRequest(t, retryClient(server, 3), http.MethodGet)
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("status = %d, want 200", resp.StatusCode)
+	}
+	if got := atomic.LoadInt32(requests); got != 3 {
+		t.Errorf("requests = %d, want 3", got)
+	}
+}
+
+func TestDoStopsAfterMaxRetries(t *testing.T) {
+	server, requests := statusSequence(t, nil, 500, 500, 500, 500, 500)
+
+	resp := doRequest(t, retryClient(server, 2), http.MethodGet)
+	if resp.StatusCode != http.StatusInternalServerError {
+		t.Errorf("status = %d, want 500", resp.StatusCode)
+	}
+	if got := atomic.LoadInt32(requests); got != 3 {
+		t.Errorf("requests = %d, want 3 (first attempt and 2 retries)", got)
+	}
+}
+
+func TestDoRetriesDisabled(t *testing.T) {
+	server, requests := statusSequence(t, nil, http.StatusServiceUnavailable)
+
+	resp := doRequest(t, retryClient(server, -1), http.MethodGet)
+	if resp.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("status = %d, want 503", resp.StatusCode)
+	}
+	if got := atomic.LoadInt32(requests); got != 1 {
+		t.Errorf("requests = %d, want 1 with max_retries: -1", got)
+	}
+}
+
+func TestDoDoesNotRetryUnsafeMethods(t *testing.T) {
+	for _, method := range []string{http.MethodDelete, http.MethodPost} {
+		server, requests := statusSequence(t, nil, http.StatusServiceUnavailable)
+
+		resp := doRequest(t, retryClient(server, 3), method)
+		if resp.StatusCode != http.StatusServiceUnavailable {
+			t.Errorf("%s: status = %d, want 503", method, resp.StatusCode)
+		}
+		if got := atomic.LoadInt32(requests); got != 1 {
+			t.Errorf("%s: requests = %d, want 1", method, got)
+		}
+	}
+}
+
+func TestDoHonoursRetryAfter(t *testing.T) {
+	server, requests := statusSequence(t, http.Header{"Retry-After": {"0"}}, http.StatusTooManyRequests)
+
+	resp := doRequest(t, retryClient(server, 3), http.MethodGet)
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("status = %d, want 200", resp.StatusCode)
+	}
+	if got := atomic.LoadInt32(requests); got != 2 {
+		t.Errorf("requests = %d, want 2", got)
+	}
+}
+
+func TestDoReturnsLongRetryAfter(t *testing.T) {
+	// Retry-After больше max_backoff не ждем: ответ 429 возвращается сразу
+	server, requests := statusSequence(t, http.Header{"Retry-After": {"3600"}}, http.StatusTooManyRequests)
+
+	start := time.Now()
+	resp := doRequest(t, retryClient(server, 3), http.MethodGet)
+	if resp.StatusCode != http.StatusTooManyRequests {
+		t.Errorf("status = %d, want 429", resp.StatusCode)
+	}
+	if got := atomic.LoadInt32(requests); got != 1 {
+		t.Errorf("requests = %d, want 1", got)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("waited %v for a Retry-After beyond max_backoff", elapsed)
+	}
+}
+
+func TestDoRecordsRateLimit(t *testing.T) {
+	header := http.Header{"Ratelimit-Limit": {"100;w=21600"}, "Ratelimit-Remaining": {"76;w=21600"}}
+	server, _ := statusSequence(t, header)
+
+	client := retryClient(server, -1)
+	doRequest(t, client, http.MethodGet)
+
+	limit := client.RateLimit()
+	if limit == nil || limit.Limit != 100 || limit.Remaining != 76 || limit.Window != 21600 {
+		t.Errorf("RateLimit = %+v, want 76 of 100 per 21600s", limit)
+	}
+}
@@ -0,0 +1,171 @@
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+)
+
+// TagSize размер тега с учетом общих слоев
+type TagSize struct {
+	Tag           string `json:"tag"`
+	Digest        string `json:"digest"`
+	Size          int64  `json:"size"`          // config + слои всех платформ
+	ExclusiveSize int64  `json:"exclusiveSize"` // Освободится при удалении только этого тега
+}
+
+// TagError тег, манифест которого не удалось получить
+type TagError struct {
+	Tag   string `json:"tag"`
+	Error string `json:"error"`
+}
+
+// RepositoryStorage фактический объем хранения репозитория по уникальным blob
+type RepositoryStorage struct {
+	ImagesSize int64            `json:"imagesSize"` // Сумма размеров образов всех тегов
+	UniqueSize int64            `json:"uniqueSize"` // Размер уникальных blob (config + слои)
+	Tags       []TagSize        `json:"tags"`
+	FailedTags []TagError       `json:"failedTags,omitempty"` // Не учтены в размерах
+	Blobs      map[string]int64 `json:"-"`                    // Уникальные blob репозитория: digest -> размер
+}
+
+// GetRepositoryStorage считает объем хранения тегов: blob, общие для нескольких тегов,
+// учитываются один раз. Манифесты запрашиваются параллельно, не более max_concurrency
+// запросов к реестру одновременно. Теги, манифест которых получить не удалось, не входят
+// в размеры и возвращаются в FailedTags
+func (c *Client) GetRepositoryStorage(ctx context.Context, repository string, tags []string) (*RepositoryStorage, error) {
+	type tagManifest struct {
+		digest string
+		blobs  map[string]int64
+		err    error
+	}
+
+	var cacheMutex sync.Mutex
+	manifestBlobs := make(map[string]map[string]int64) // digest манифеста -> его blob
+	results := make([]*tagManifest, len(tags))
+
+	err := runBounded(ctx, len(tags), c.concurrency(), func(i int) {
+		raw, err := c.fetchManifest(ctx, repository, tags[i])
+		if err != nil {
+			results[i] = &tagManifest{err: err}
+			return
+		}
+
+		cacheMutex.Lock()
+		blobs, exists := manifestBlobs[raw.Digest]
+		cacheMutex.Unlock()
+
+		if !exists {
+			blobs, err = c.manifestBlobs(ctx, repository, raw)
+			if err != nil {
+				results[i] = &tagManifest{err: err}
+				return
+			}
+			cacheMutex.Lock()
+			manifestBlobs[raw.Digest] = blobs
+			cacheMutex.Unlock()
+		}
+
+		results[i] = &tagManifest{digest: raw.Digest, blobs: blobs}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	storage := &RepositoryStorage{
+		Tags:  make([]TagSize, 0, len(tags)),
+		Blobs: make(map[string]int64),
+	}
+	blobTags := make(map[string]int) // digest blob -> количество тегов
+	tagBlobs := make([]map[string]int64, 0, len(tags))
+
+	for i, result := range results {
+		if result.err != nil {
+			storage.FailedTags = append(storage.FailedTags, TagError{Tag: tags[i], Error: result.err.Error()})
+			continue
+		}
+
+		tagSize := TagSize{Tag: tags[i], Digest: result.digest}
+		for digest, size := range result.blobs {
+			tagSize.Size += size
+			blobTags[digest]++
+			storage.Blobs[digest] = size
+		}
+
+		storage.ImagesSize += tagSize.Size
+		storage.Tags = append(storage.Tags, tagSize)
+		tagBlobs = append(tagBlobs, result.blobs)
+	}
+
+	for _, size := range storage.Blobs {
+		storage.UniqueSize += size
+	}
+
+	for i := range storage.Tags {
+		for digest, size := range tagBlobs[i] {
+			if blobTags[digest] == 1 {
+				storage.Tags[i].ExclusiveSize += size
+			}
+		}
+	}
+
+	return storage, nil
+}
+
+// manifestBlobs собирает config и слои манифеста; для multi-arch образов — всех
+// вложенных манифестов, включая attestations
+func (c *Client) manifestBlobs(ctx context.Context, repository string, raw *rawManifest) (map[string]int64, error) {
+	blobs := make(map[string]int64)
+
+	if !isIndexMediaType(raw.MediaType) {
+		if err := addManifestBlobs(raw.Body, blobs); err != nil {
+			return nil, err
+		}
+		return blobs, nil
+	}
+
+	var index imageIndex
+	if err := json.Unmarshal(raw.Body, &index); err != nil {
+		return nil, fmt.Errorf("failed to parse image index: %w", err)
+	}
+
+	// Вложенные манифесты запрашиваются параллельно; общее число запросов к реестру
+	// ограничивает семафор клиента
+	children := make([]*rawManifest, len(index.Manifests))
+	errs := make([]error, len(index.Manifests))
+	err := runBounded(ctx, len(index.Manifests), c.concurrency(), func(i int) {
+		children[i], errs[i] = c.fetchManifest(ctx, repository, index.Manifests[i].Digest)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for i, child := range children {
+		if errs[i] != nil {
+			return nil, errs[i]
+		}
+		if err := addManifestBlobs(child.Body, blobs); err != nil {
+			return nil, err
+		}
+	}
+
+	return blobs, nil
+}
+
+// addManifestBlobs добавляет config и слои манифеста образа в набор blob
+func addManifestBlobs(body []byte, blobs map[string]int64) error {
+	var manifest imageManifest
+	if err := json.Unmarshal(body, &manifest); err != nil {
+		return fmt.Errorf("failed to parse manifest: %w", err)
+	}
+
+	if manifest.Config.Digest != "" {
+		blobs[manifest.Config.Digest] = manifest.Config.Size
+	}
+	for _, layer := range manifest.Layers {
+		blobs[layer.Digest] = layer.Size
+	}
+
+	return nil
+}
@@ -0,0 +1,72 @@
+package registry
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+func TestGetRepositoryStorageSharedLayers(t *testing.T) {
+	tr := newTestRegistry(t)
+	base := []testFile{{Name: "etc/os-release", Body: "base"}}
+	tr.addImage("app", "v1", base, []testFile{{Name: "app", Body: "v1"}})
+	tr.addImage("app", "v2", base, []testFile{{Name: "app", Body: "v2"}})
+
+	storage, err := tr.client().GetRepositoryStorage(context.Background(), "app", []string{"v1", "v2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(storage.Tags) != 2 || len(storage.FailedTags) != 0 {
+		t.Fatalf("tags = %+v, failed = %+v", storage.Tags, storage.FailedTags)
+	}
+	// Общие config и базовый слой учитываются один раз
+	if storage.UniqueSize >= storage.ImagesSize {
+		t.Errorf("UniqueSize = %d, want less than ImagesSize %d", storage.UniqueSize, storage.ImagesSize)
+	}
+	for _, tag := range storage.Tags {
+		if tag.ExclusiveSize <= 0 || tag.ExclusiveSize >= tag.Size {
+			t.Errorf("%s: exclusive %d of %d, want only the top layer", tag.Tag, tag.ExclusiveSize, tag.Size)
+		}
+	}
+}
+
+func TestGetRepositoryStorageReportsFailedTags(t *testing.T) {
+	tr := newTestRegistry(t)
+	tr.addImage("app", "v1", []testFile{{Name: "app", Body: "v1"}})
+	tr.addImage("app", "v2", []testFile{{Name: "app", Body: "v2"}})
+	tr.fail("app", "v2", http.StatusTooManyRequests)
+
+	storage, err := tr.client().GetRepositoryStorage(context.Background(), "app", []string{"v1", "v2", "missing"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(storage.Tags) != 1 || storage.Tags[0].Tag != "v1" {
+		t.Errorf("tags = %+v, want only v1", storage.Tags)
+	}
+	if len(storage.FailedTags) != 2 || storage.FailedTags[0].Tag != "v2" || storage.FailedTags[1].Tag != "missing" {
+		t.Fatalf("FailedTags = %+v, want v2 and missing", storage.FailedTags)
+	}
+	if storage.FailedTags[0].Error == "" {
+		t.Error("failed tag without error message")
+	}
+}
+
+func TestGetRepositoryInfoIncompleteSize(t *testing.T) {
+	tr := newTestRegistry(t)
+	tr.addImage("app", "v1", []testFile{{Name: "app", Body: "v1"}})
+	tr.addImage("app", "v2", []testFile{{Name: "app", Body: "v2"}})
+	tr.fail("app", "v2", http.StatusTooManyRequests)
+
+	info, err := tr.client().GetRepositoryInfo(context.Background(), "app")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.IsEstimate || len(info.TagSizes) != 1 {
+		t.Errorf("info = %+v, want exact size of v1", info)
+	}
+	if len(info.FailedTags) != 1 || info.FailedTags[0].Tag != "v2" {
+		t.Errorf("FailedTags = %+v, want v2", info.FailedTags)
+	}
+}
@@ -0,0 +1,107 @@
+package registry
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Каталог сертификатов реестров по соглашению Docker: <host[:port]>/*.crt, *.cert, *.key
+var dockerCertsDir = "/etc/docker/certs.d"
+
+// tlsConfig собирает TLS настройки реестра: ca_file, cert_file/key_file, insecure_skip_verify (insecure)
+// и сертификаты из /etc/docker/certs.d/<host>/. Явно заданный клиентский сертификат
+// имеет приоритет над найденными в каталоге Docker
+func (c *Client) tlsConfig() (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		InsecureSkipVerify: c.registry.InsecureSkipVerify || c.registry.Insecure,
+	}
+
+	var caFiles []string
+	var certPairs [][2]string
+	if host := registryHost(c.registry.URL); host != "" {
+		var err error
+		caFiles, certPairs, err = dockerCertFiles(filepath.Join(dockerCertsDir, host))
+		if err != nil {
+			return nil, err
+		}
+	}
+	if c.registry.CAFile != "" {
+		caFiles = append(caFiles, c.registry.CAFile)
+	}
+
+	if len(caFiles) > 0 {
+		roots, err := x509.SystemCertPool()
+		if err != nil {
+			roots = x509.NewCertPool()
+		}
+		for _, caFile := range caFiles {
+			data, err := os.ReadFile(caFile)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read CA file: %w", err)
+			}
+			if !roots.AppendCertsFromPEM(data) {
+				return nil, fmt.Errorf("no PEM certificates found in %s", caFile)
+			}
+		}
+		tlsConfig.RootCAs = roots
+	}
+
+	if c.registry.CertFile != "" || c.registry.KeyFile != "" {
+		if c.registry.CertFile == "" || c.registry.KeyFile == "" {
+			return nil, fmt.Errorf("cert_file and key_file must be set together")
+		}
+		certPairs = [][2]string{{c.registry.CertFile, c.registry.KeyFile}}
+	}
+
+	for _, pair := range certPairs {
+		certificate, err := tls.LoadX509KeyPair(pair[0], pair[1])
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate %s: %w", pair[0], err)
+		}
+		tlsConfig.Certificates = append(tlsConfig.Certificates, certificate)
+	}
+
+	return tlsConfig, nil
+}
+
+// dockerCertFiles находит в каталоге реестра корневые сертификаты (*.crt) и пары
+// клиентских сертификатов (*.cert + *.key). Отсутствие каталога не является ошибкой
+func dockerCertFiles(dir string) (caFiles []string, certPairs [][2]string, err error) {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read certificates directory: %w", err)
+	}
+
+	for _, entry := range entries {
+		name := entry.Name()
+		switch {
+		case strings.HasSuffix(name, ".crt"):
+			caFiles = append(caFiles, filepath.Join(dir, name))
+		case strings.HasSuffix(name, ".cert"):
+			keyName := strings.TrimSuffix(name, ".cert") + ".key"
+			if _, err := os.Stat(filepath.Join(dir, keyName)); err != nil {
+				return nil, nil, fmt.Errorf("missing key %s for client certificate %s in %s", keyName, name, dir)
+			}
+			certPairs = append(certPairs, [2]string{filepath.Join(dir, name), filepath.Join(dir, keyName)})
+		}
+	}
+
+	return caFiles, certPairs, nil
+}
+
+// registryHost возвращает host[:port] реестра из URL
+func registryHost(registryURL string) string {
+	parsed, err := url.Parse(registryURL)
+	if err != nil {
+		return ""
+	}
+	return parsed.Host
+}
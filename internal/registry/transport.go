@@ -0,0 +1,97 @@
+package registry
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"golang.org/x/net/http/httpproxy"
+)
+
+// Таймауты по умолчанию, если connect_timeout и request_timeout не заданы
+const (
+	defaultConnectTimeout = 10 * time.Second
+	defaultRequestTimeout = 30 * time.Second
+)
+
+// Время жизни простаивающего соединения с реестром
+const idleConnTimeout = 90 * time.Second
+
+// timeouts возвращает таймауты соединения и ожидания ответа для реестра
+func (c *Client) timeouts() (connectTimeout, requestTimeout time.Duration) {
+	connectTimeout, requestTimeout = defaultConnectTimeout, defaultRequestTimeout
+	if c.registry.ConnectTimeout > 0 {
+		connectTimeout = c.registry.ConnectTimeout
+	}
+	if c.registry.RequestTimeout > 0 {
+		requestTimeout = c.registry.RequestTimeout
+	}
+	return connectTimeout, requestTimeout
+}
+
+// newTransport создает транспорт реестра с keep-alive соединениями, HTTP/2 и прокси.
+// Простаивающих соединений держим не меньше max_concurrency, чтобы параллельные
+// запросы не открывали соединения заново. requestTimeout ограничивает ожидание
+// заголовков ответа, но не чтение тела: слои могут скачиваться дольше, их отмена
+// идет через context. Ошибка прокси или TLS настроек возвращается вместе с транспортом
+func (c *Client) newTransport() (*http.Transport, error) {
+	connectTimeout, requestTimeout := c.timeouts()
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = (&net.Dialer{
+		Timeout:   connectTimeout,
+		KeepAlive: 30 * time.Second,
+	}).DialContext
+	transport.ForceAttemptHTTP2 = true
+	transport.TLSHandshakeTimeout = connectTimeout
+	transport.ResponseHeaderTimeout = requestTimeout
+	transport.IdleConnTimeout = idleConnTimeout
+	transport.MaxIdleConnsPerHost = c.concurrency()
+	transport.MaxConnsPerHost = c.registry.MaxConnsPerHost
+
+	proxy, err := c.proxyFunc()
+	if err != nil {
+		return transport, fmt.Errorf("invalid proxy settings: %w", err)
+	}
+	transport.Proxy = proxy
+
+	tlsConfig, err := c.tlsConfig()
+	if err != nil {
+		return transport, fmt.Errorf("invalid TLS settings: %w", err)
+	}
+	transport.TLSClientConfig = tlsConfig
+
+	return transport, nil
+}
+
+// proxyFunc выбирает прокси для запросов к реестру. Без proxy и no_proxy в inventory
+// используются HTTP_PROXY, HTTPS_PROXY и NO_PROXY; proxy заменяет прокси из окружения
+// для обеих схем, no_proxy — список исключений. Поддерживаются http, https, socks5 и socks5h
+func (c *Client) proxyFunc() (func(*http.Request) (*url.URL, error), error) {
+	proxyConfig := httpproxy.FromEnvironment()
+
+	if c.registry.Proxy != "" {
+		proxyURL, err := url.Parse(c.registry.Proxy)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse proxy URL: %w", err)
+		}
+		switch proxyURL.Scheme {
+		case "http", "https", "socks5", "socks5h":
+		default:
+			return nil, fmt.Errorf("unsupported proxy scheme %q", proxyURL.Scheme)
+		}
+		proxyConfig.HTTPProxy = c.registry.Proxy
+		proxyConfig.HTTPSProxy = c.registry.Proxy
+	}
+	if len(c.registry.NoProxy) > 0 {
+		proxyConfig.NoProxy = strings.Join(c.registry.NoProxy, ",")
+	}
+
+	proxy := proxyConfig.ProxyFunc()
+	return func(req *http.Request) (*url.URL, error) {
+		return proxy(req.URL)
+	}, nil
+}
@@ -4,9 +4,19 @@ class RegLiteApp {
         this.currentRegistry = null;
         this.currentRepository = null;
         this.currentManifest = null;
+        this.currentTag = null;
+        this.activeTagPanel = null; // Открытая панель в информации о теге
+        this.currentDockerfile = '';
+        this.currentFiles = []; // Записи открытой файловой системы или слоя
+        this.currentFilesLayer = ''; // Digest просматриваемого слоя, пусто для итоговой файловой системы
+        this.currentTags = []; // Теги открытого репозитория
+        this.storagePollTimer = null; // Опрос состояния анализа хранилища
+        this.fileListLimit = 500; // Максимум строк в списке файлов
         this.registriesData = [];
         this.validationInProgress = false;
         this.repositoryInfoCache = new Map(); // Кэш информации о репозиториях
+        this.repositoriesPageSize = 100; // Размер страницы каталога
+        this.repositoriesNext = null; // Курсор следующей страницы каталога
     }
 
     async init() {
@@ -111,6 +121,19 @@ class RegLiteApp {
             this.refreshTags();
         });
 
+        // Анализ хранилища реестра
+        document.getElementById('show-storage').addEventListener('click', () => {
+            this.showStorage();
+        });
+
+        document.getElementById('start-storage-analysis').addEventListener('click', () => {
+            this.startStorageAnalysis();
+        });
+
+        document.getElementById('storage-back-to-repos').addEventListener('click', () => {
+            this.showRepositories(this.currentRegistry);
+        });
+
         // Кнопка валидации реестров
         document.getElementById('validate-registries').addEventListener('click', () => {
             this.validateRegistries();
@@ -659,6 +682,14 @@ class RegLiteApp {
             return time < 1000 ? `${time}ms` : `${(time / 1000).toFixed(1)}s`;
         };
 
+        // Функция для форматирования остатка лимита запросов
+        const formatRateLimit = (rateLimit) => {
+            if (!rateLimit) return '';
+            const limit = rateLimit.limit ? `/${rateLimit.limit}` : '';
+            const hours = rateLimit.window ? ` за ${Math.round(rateLimit.window / 3600)} ч` : '';
+            return `${rateLimit.remaining}${limit}${hours}`;
+        };
+
         // Функция для рендеринга одного реестра
         const renderRegistry = (registry) => {
             const escapedName = escapeHtml(registry.name);
@@ -686,6 +717,8 @@ class RegLiteApp {
                                 ${registry.status === 'online' ? '<span>✓ Доступен</span>' : 
                                   registry.status === 'offline' ? '<span>✗ Недоступен</span>' : 
                                   '<span>⏳ Проверяется</span>'}
+                                ${registry.rateLimit ? 
+                                    `<span class="registry-rate-limit ${registry.rateLimit.remaining === 0 ? 'exhausted' : ''}" title="Осталось запросов">${formatRateLimit(registry.rateLimit)}</span>` : ''}
                             </div>
                             ${registry.errorMessage ? 
                                 `<div class="registry-error" title="${escapeHtml(registry.errorMessage)}">${escapeHtml(registry.errorMessage)}</div>` : ''}
@@ -785,6 +818,7 @@ class RegLiteApp {
         document.getElementById('welcome-section').style.display = 'block';
         document.getElementById('repositories-section').style.display = 'none';
         document.getElementById('tags-section').style.display = 'none';
+        document.getElementById('storage-section').style.display = 'none';
         
         this.currentRegistry = null;
         this.currentRepository = null;
@@ -829,6 +863,7 @@ class RegLiteApp {
         // Показываем секцию репозиториев с плавным переходом
         document.getElementById('welcome-section').style.display = 'none';
         document.getElementById('tags-section').style.display = 'none';
+        document.getElementById('storage-section').style.display = 'none';
         document.getElementById('repositories-section').style.display = 'block';
         
         // Обновляем URL, если нужно
@@ -848,16 +883,9 @@ class RegLiteApp {
         repositoriesList.innerHTML = '<div class="loading">Загружаем репозитории...</div>';
         
         try {
-            const url = `/api/v1/repositories?registry=${encodeURIComponent(registryName)}`;
-            
-            const response = await fetch(url);
-            const data = await response.json();
-            
-            if (!response.ok) {
-                throw new Error(data.error || 'Ошибка загрузки репозиториев');
-            }
+            const data = await this.fetchRepositoriesPage(registryName);
             
-            this.renderRepositories(data.repositories);
+            this.renderRepositories(data.repositories, data.next);
         } catch (error) {
             this.showToast('Ошибка загрузки репозиториев: ' + error.message, 'error');
             repositoriesList.innerHTML = `
@@ -874,20 +902,47 @@ class RegLiteApp {
         }
     }
 
-    renderRepositories(repositories) {
-        const container = document.getElementById('repositories-list');
+    // Загрузка одной страницы каталога
+    async fetchRepositoriesPage(registryName, last = '') {
+        let url = `/api/v1/repositories?registry=${encodeURIComponent(registryName)}&n=${this.repositoriesPageSize}`;
+        if (last) {
+            url += `&last=${encodeURIComponent(last)}`;
+        }
         
-        if (!repositories || repositories.length === 0) {
-            container.innerHTML = `
-                <div class="card">
-                    <h4><i class="fas fa-info-circle"></i> Репозитории не найдены</h4>
-                    <p>В этом реестре нет доступных репозиториев</p>
-                </div>
-            `;
-            return;
+        const response = await fetch(url);
+        const data = await response.json();
+        
+        if (!response.ok) {
+            throw new Error(data.error || 'Ошибка загрузки репозиториев');
         }
+        
+        return data;
+    }
+
+    // Подгрузка следующей страницы репозиториев
+    async loadMoreRepositories() {
+        if (!this.currentRegistry || !this.repositoriesNext) return;
+        
+        const button = document.getElementById('load-more-repositories');
+        if (button) {
+            button.disabled = true;
+            button.innerHTML = '<i class="fas fa-spinner fa-spin"></i> Загружаем...';
+        }
+        
+        try {
+            const data = await this.fetchRepositoriesPage(this.currentRegistry, this.repositoriesNext);
+            this.appendRepositories(data.repositories || [], data.next);
+        } catch (error) {
+            this.showToast('Ошибка загрузки репозиториев: ' + error.message, 'error');
+            if (button) {
+                button.disabled = false;
+                button.innerHTML = '<i class="fas fa-chevron-down"></i> Загрузить ещё';
+            }
+        }
+    }
 
-        container.innerHTML = repositories.map(repo => `
+    renderRepositoryCard(repo) {
+        return `
             <div class="card repository-card" onclick="app.selectRepository('${repo}')" role="button" tabindex="0" data-repo="${repo}">
                 <h4><i class="fas fa-folder"></i> ${repo}</h4>
                 <div class="repository-stats loading" id="repo-stats-${repo.replace(/[^a-zA-Z0-9]/g, '_')}">
@@ -903,10 +958,53 @@ class RegLiteApp {
                     </button>
                 </div>
             </div>
-        `).join('');
+        `;
+    }
+
+    renderRepositories(repositories, next = null) {
+        const container = document.getElementById('repositories-list');
+        
+        if (!repositories || repositories.length === 0) {
+            this.repositoriesNext = null;
+            container.innerHTML = `
+                <div class="card">
+                    <h4><i class="fas fa-info-circle"></i> Репозитории не найдены</h4>
+                    <p>В этом реестре нет доступных репозиториев</p>
+                </div>
+            `;
+            return;
+        }
+
+        container.innerHTML = '';
+        this.appendRepositories(repositories, next);
+    }
+
+    // Добавление страницы репозиториев в конец списка
+    appendRepositories(repositories, next = null) {
+        const container = document.getElementById('repositories-list');
+        this.repositoriesNext = next || null;
+        
+        const loadMore = document.getElementById('load-more-repositories-container');
+        if (loadMore) {
+            loadMore.remove();
+        }
+
+        container.insertAdjacentHTML('beforeend', repositories.map(repo => this.renderRepositoryCard(repo)).join(''));
+
+        if (this.repositoriesNext) {
+            container.insertAdjacentHTML('beforeend', `
+                <div class="load-more-container" id="load-more-repositories-container">
+                    <button class="btn btn-secondary" id="load-more-repositories" onclick="app.loadMoreRepositories()">
+                        <i class="fas fa-chevron-down"></i> Загрузить ещё
+                    </button>
+                </div>
+            `);
+        }
 
         // Добавляем поддержку навигации с клавиатуры
-        container.querySelectorAll('.card').forEach(card => {
+        repositories.forEach(repo => {
+            const card = container.querySelector(`.card[data-repo="${repo}"]`);
+            if (!card) return;
             card.addEventListener('keydown', (e) => {
                 if (e.key === 'Enter' || e.key === ' ') {
                     e.preventDefault();
@@ -989,6 +1087,10 @@ class RegLiteApp {
         if (statsContainer) {
             const sizeText = this.formatSize(repositoryInfo.totalSize);
             const estimateText = repositoryInfo.isEstimate ? '<small>~</small>' : '';
+            const failedCount = (repositoryInfo.failedTags || []).length;
+            const incompleteText = failedCount
+                ? ` <i class="fas fa-exclamation-triangle size-incomplete" title="Размер неполный: не удалось получить ${failedCount} тегов"></i>`
+                : '';
             
             statsContainer.className = 'repository-stats';
             statsContainer.innerHTML = `
@@ -1000,7 +1102,7 @@ class RegLiteApp {
                 <div class="stat-item">
                     <i class="fas fa-hdd"></i>
                     <span class="stat-label">Размер:</span>
-                    <span class="stat-value">${estimateText}${sizeText}</span>
+                    <span class="stat-value">${estimateText}${sizeText}${incompleteText}</span>
                 </div>
             `;
         }
@@ -1101,7 +1203,7 @@ class RegLiteApp {
         
         if (archElement) {
             archElement.className = 'stat-value';
-            archElement.innerHTML = manifestInfo.architecture || 'N/A';
+            archElement.innerHTML = this.escapeHtml(manifestInfo.architecture || this.shortArtifactType(manifestInfo.artifactType) || 'N/A');
         }
         
         if (createdElement) {
@@ -1269,13 +1371,24 @@ class RegLiteApp {
                 </div>
                 ${repositoryInfo.totalSize ? `
                 <div class="repository-info-item">
-                    <span class="repository-info-label">Общий размер:</span>
+                    <span class="repository-info-label">Общий размер${repositoryInfo.isEstimate ? '' : ' (уникальные слои)'}:</span>
                     <span class="repository-info-value">
                         ${repositoryInfo.isEstimate ? '<i class="fas fa-tilde" title="Приблизительно"></i> ' : ''}${formattedSize}
                         ${repositoryInfo.isEstimate ? `<br><small style="color: var(--text-muted); font-size: 0.75rem;">(оценка на основе ${repositoryInfo.sampleTagsCount} тегов)</small>` : ''}
                     </span>
                 </div>
                 ` : ''}
+                ${repositoryInfo.imagesSize ? `
+                <div class="repository-info-item">
+                    <span class="repository-info-label">Сумма размеров образов:</span>
+                    <span class="repository-info-value">
+                        ${this.formatSize(repositoryInfo.imagesSize)}
+                        <br><small style="color: var(--text-muted); font-size: 0.75rem;">(общие слои учтены в каждом теге)</small>
+                    </span>
+                </div>
+                ` : ''}
+                ${this.renderFailedTags(repositoryInfo.failedTags)}
+                ${this.renderTagSizes(repositoryInfo.tagSizes)}
                 ${repositoryInfo.description ? `
                 <div class="repository-info-item">
                     <span class="repository-info-label">Описание:</span>
@@ -1294,6 +1407,50 @@ class RegLiteApp {
         }
     }
 
+    // Теги, манифест которых не удалось получить: размер репозитория без них неполный
+    renderFailedTags(failedTags) {
+        if (!failedTags || failedTags.length === 0) return '';
+        
+        return `
+            <div class="repository-info-item">
+                <span class="repository-info-label">
+                    <i class="fas fa-exclamation-triangle size-incomplete"></i> Размер неполный, не учтено тегов: ${failedTags.length}
+                </span>
+                <span class="repository-info-value">
+                    ${failedTags.map(failed => `
+                        <small><code>${this.escapeHtml(failed.tag)}</code>: ${this.escapeHtml(failed.error)}</small>
+                    `).join('<br>')}
+                </span>
+            </div>
+        `;
+    }
+
+    // Размеры тегов: полный и освобождаемый при удалении только этого тега
+    renderTagSizes(tagSizes) {
+        if (!tagSizes || tagSizes.length === 0) return '';
+        
+        const rows = [...tagSizes]
+            .sort((a, b) => b.exclusiveSize - a.exclusiveSize)
+            .map(tag => `
+                <div class="tag-size-row">
+                    <span class="tag-size-name">${this.escapeHtml(tag.tag)}</span>
+                    <span class="platform-size">${this.formatSize(tag.size)}</span>
+                    <span class="tag-size-exclusive" title="Освободится при удалении только этого тега">${this.formatSize(tag.exclusiveSize)}</span>
+                </div>
+            `).join('');
+        
+        return `
+            <div class="tag-sizes">
+                <div class="tag-size-row tag-size-header">
+                    <span class="tag-size-name">Тег</span>
+                    <span>Размер</span>
+                    <span>Освободится</span>
+                </div>
+                ${rows}
+            </div>
+        `;
+    }
+
     viewRepositoryTags() {
         if (this.currentRepository) {
             this.closeRepositoryModal();
@@ -1301,6 +1458,178 @@ class RegLiteApp {
         }
     }
 
+    // Показать дашборд хранилища текущего реестра
+    async showStorage() {
+        if (!this.currentRegistry) return;
+        
+        document.getElementById('repositories-section').style.display = 'none';
+        document.getElementById('storage-section').style.display = 'block';
+        document.getElementById('storage-registry').textContent = this.currentRegistry;
+        
+        const content = document.getElementById('storage-content');
+        content.innerHTML = '<div class="loading">Загружаем...</div>';
+        
+        await this.loadStorageAnalysis();
+    }
+
+    // Запуск анализа хранилища в фоне
+    async startStorageAnalysis() {
+        try {
+            const response = await fetch(`/api/v1/storage/analysis?registry=${encodeURIComponent(this.currentRegistry)}`, { method: 'POST' });
+            const data = await response.json();
+            
+            if (!response.ok) {
+                throw new Error(data.error || 'Ошибка запуска анализа');
+            }
+            
+            this.renderStorageAnalysis(data);
+            this.scheduleStoragePoll();
+        } catch (error) {
+            this.showToast('Ошибка запуска анализа: ' + error.message, 'error');
+        }
+    }
+
+    // Загрузка состояния последнего анализа
+    async loadStorageAnalysis() {
+        const registryName = this.currentRegistry;
+        const content = document.getElementById('storage-content');
+        
+        try {
+            const response = await fetch(`/api/v1/storage/analysis?registry=${encodeURIComponent(registryName)}`);
+            const data = await response.json();
+            
+            // Пользователь мог уйти с дашборда или сменить реестр
+            if (registryName !== this.currentRegistry || document.getElementById('storage-section').style.display !== 'block') {
+                return;
+            }
+            
+            if (response.status === 404) {
+                content.innerHTML = `
+                    <div class="card">
+                        <h4><i class="fas fa-info-circle"></i> Анализ не выполнялся</h4>
+                        <p>Анализ обходит все репозитории и теги реестра и может занять продолжительное время</p>
+                    </div>
+                `;
+                return;
+            }
+            
+            if (!response.ok) {
+                throw new Error(data.error || 'Ошибка загрузки анализа');
+            }
+            
+            this.renderStorageAnalysis(data);
+            if (data.status === 'running') {
+                this.scheduleStoragePoll();
+            }
+        } catch (error) {
+            content.innerHTML = `
+                <div class="single-loader error">
+                    <i class="fas fa-exclamation-triangle"></i>
+                    <span>${this.escapeHtml(error.message)}</span>
+                </div>
+            `;
+        }
+    }
+
+    // Повторный запрос состояния, пока анализ выполняется
+    scheduleStoragePoll() {
+        clearTimeout(this.storagePollTimer);
+        this.storagePollTimer = setTimeout(() => this.loadStorageAnalysis(), 2000);
+    }
+
+    // Дашборд анализа хранилища
+    renderStorageAnalysis(analysis) {
+        const content = document.getElementById('storage-content');
+        const button = document.getElementById('start-storage-analysis');
+        button.disabled = analysis.status === 'running';
+        
+        if (analysis.status === 'running') {
+            const percent = analysis.total ? Math.round(analysis.processed / analysis.total * 100) : 0;
+            content.innerHTML = `
+                <div class="card">
+                    <h4><i class="fas fa-spinner fa-spin"></i> Анализ выполняется</h4>
+                    <p>Обработано репозиториев: ${analysis.processed} из ${analysis.total || '?'}</p>
+                    <div class="storage-progress"><div class="storage-progress-bar" style="width: ${percent}%"></div></div>
+                </div>
+            `;
+            return;
+        }
+        
+        if (analysis.status === 'failed') {
+            content.innerHTML = `
+                <div class="single-loader error">
+                    <i class="fas fa-exclamation-triangle"></i>
+                    <span>${this.escapeHtml(analysis.errorMessage || 'Анализ завершился с ошибкой')}</span>
+                </div>
+            `;
+            return;
+        }
+        
+        const report = analysis.report;
+        const finishedAt = analysis.finishedAt ? new Date(analysis.finishedAt).toLocaleString('ru-RU') : '';
+        const errors = Object.entries(report.errors || {});
+        
+        const sharedRows = report.sharedLayers.map(layer => `
+            <div class="storage-row">
+                <code class="platform-digest" title="${this.escapeHtml(layer.digest)}">${this.escapeHtml(layer.digest.substring(7, 19))}</code>
+                <span>${this.formatSize(layer.size)}</span>
+                <span title="${this.escapeHtml(layer.repositories.join(', '))}">${layer.repositoriesCount}</span>
+                <span>${this.formatSize(layer.savedSize)}</span>
+            </div>
+        `).join('');
+        
+        const repositoryRows = report.topRepositories.map(repo => `
+            <div class="storage-row">
+                <span class="storage-name">${this.escapeHtml(repo.name)}</span>
+                <span>${repo.tagsCount}</span>
+                <span>${this.formatSize(repo.uniqueSize)}</span>
+                <span>${this.formatSize(repo.exclusiveSize)}</span>
+            </div>
+        `).join('');
+        
+        content.innerHTML = `
+            <div class="storage-summary">
+                <div class="card">
+                    <span class="stat-label">Уникальные данные</span>
+                    <h4>${this.formatSize(report.uniqueSize)}</h4>
+                    <small>${report.blobsCount} blob</small>
+                </div>
+                <div class="card">
+                    <span class="stat-label">Сумма репозиториев</span>
+                    <h4>${this.formatSize(report.repositoriesSize)}</h4>
+                    <small>без учета общих слоев между репозиториями</small>
+                </div>
+                <div class="card">
+                    <span class="stat-label">Сумма образов</span>
+                    <h4>${this.formatSize(report.imagesSize)}</h4>
+                    <small>${report.repositoriesCount} репозиториев, ${report.tagsCount} тегов</small>
+                </div>
+            </div>
+            <div class="tag-details-section">
+                <h5><i class="fas fa-layer-group"></i> Общие слои</h5>
+                <div class="storage-row storage-header">
+                    <span>Digest</span><span>Размер</span><span>Репозиториев</span><span>Экономия</span>
+                </div>
+                ${sharedRows || '<p class="referrers-empty">Общих слоев между репозиториями нет</p>'}
+            </div>
+            <div class="tag-details-section">
+                <h5><i class="fas fa-trophy"></i> Крупнейшие репозитории</h5>
+                <div class="storage-row storage-header">
+                    <span>Репозиторий</span><span>Тегов</span><span>Размер</span><span>Только в нем</span>
+                </div>
+                ${repositoryRows || '<p class="referrers-empty">Репозитории не найдены</p>'}
+            </div>
+            ${errors.length > 0 ? `
+            <div class="tag-details-section">
+                <h5><i class="fas fa-exclamation-triangle"></i> Ошибки (${errors.length})</h5>
+                <p class="referrers-empty">Эти репозитории и теги не учтены: объемы и общие слои могут быть занижены</p>
+                ${errors.map(([name, message]) => `<div class="history-comment"><strong>${this.escapeHtml(name)}</strong>: ${this.escapeHtml(message)}</div>`).join('')}
+            </div>
+            ` : ''}
+            <p class="referrers-empty">Анализ завершен ${finishedAt}</p>
+        `;
+    }
+
     // Обновление списка репозиториев
     async refreshRepositories() {
         if (!this.currentRegistry) {
@@ -1363,6 +1692,7 @@ class RegLiteApp {
     async showTags(registryName, repositoryName, updateURL = true) {
         // Показываем секцию тегов
         document.getElementById('repositories-section').style.display = 'none';
+        document.getElementById('storage-section').style.display = 'none';
         document.getElementById('tags-section').style.display = 'block';
         
         // Обновляем URL, если нужно
@@ -1424,6 +1754,7 @@ class RegLiteApp {
 
         // Сортируем теги по времени (новые сначала, если есть информация о времени)
         const sortedTags = [...tags].sort().reverse();
+        this.currentTags = sortedTags;
 
         container.innerHTML = sortedTags.map(tag => `
             <div class="card tag-card" onclick="app.selectTag('${tag}')" role="button" tabindex="0" data-tag="${tag}">
@@ -1544,6 +1875,9 @@ class RegLiteApp {
         // Формируем команду docker pull
         const dockerPullCommand = `docker pull ${this.currentRegistry}/${this.currentRepository}:${tagName}`;
         
+        this.currentTag = tagName;
+        this.activeTagPanel = null;
+        
         infoContainer.innerHTML = `
             <div class="tag-info">
                 <h5><i class="fas fa-info-circle"></i> Информация о теге: ${tagName}</h5>
@@ -1588,7 +1922,40 @@ class RegLiteApp {
                     <span class="tag-info-value">${formatDate(manifest.created)}</span>
                 </div>
                 ` : ''}
+                ${manifest.artifactType ? `
+                <div class="tag-info-item">
+                    <span class="tag-info-label">Тип артефакта:</span>
+                    <span class="tag-info-value">${this.escapeHtml(manifest.artifactType)}</span>
+                </div>
+                ` : ''}
+                ${manifest.configMediaType ? `
+                <div class="tag-info-item">
+                    <span class="tag-info-label">Config Media Type:</span>
+                    <span class="tag-info-value">${this.escapeHtml(manifest.configMediaType)}</span>
+                </div>
+                ` : ''}
             </div>
+            ${manifest.isIndex ? this.renderPlatforms(tagName, manifest.platforms) : ''}
+            ${this.renderAnnotations(manifest.annotations)}
+            ${this.renderLayers(manifest.layers)}
+            <div id="tag-referrers"></div>
+            ${manifest.artifactType ? '' : `
+            <div class="tag-tools">
+                <button class="btn btn-secondary" data-panel="config" onclick="app.toggleTagPanel('config')">
+                    <i class="fas fa-cogs"></i> Конфигурация
+                </button>
+                <button class="btn btn-secondary" data-panel="history" onclick="app.toggleTagPanel('history')">
+                    <i class="fas fa-history"></i> История
+                </button>
+                <button class="btn btn-secondary" data-panel="files" onclick="app.toggleTagPanel('files')">
+                    <i class="fas fa-folder-open"></i> Файлы
+                </button>
+                <button class="btn btn-secondary" data-panel="diff" onclick="app.toggleTagPanel('diff')">
+                    <i class="fas fa-code-compare"></i> Сравнить
+                </button>
+            </div>
+            <div id="tag-panel"></div>
+            `}
         `;
         
         modal.style.display = 'block';
@@ -1598,6 +1965,668 @@ class RegLiteApp {
         if (closeButton) {
             closeButton.focus();
         }
+
+        // Асинхронно загружаем подписи, SBOM и attestations
+        if (digest) {
+            this.loadReferrers(digest);
+        }
+    }
+
+    // Переключение дополнительной панели в информации о теге
+    async toggleTagPanel(panel) {
+        const container = document.getElementById('tag-panel');
+        if (!container) return;
+        
+        const buttons = document.querySelectorAll('.tag-tools [data-panel]');
+        
+        if (this.activeTagPanel === panel) {
+            this.activeTagPanel = null;
+            container.innerHTML = '';
+            buttons.forEach(button => button.classList.remove('active'));
+            return;
+        }
+        
+        this.activeTagPanel = panel;
+        buttons.forEach(button => button.classList.toggle('active', button.dataset.panel === panel));
+        container.innerHTML = '<div class="single-loader"><i class="fas fa-spinner fa-spin"></i><span>Загружаем...</span></div>';
+        
+        try {
+            let html = '';
+            switch (panel) {
+                case 'config':
+                    html = await this.loadImageConfigPanel();
+                    break;
+                case 'history':
+                    html = await this.loadImageHistoryPanel();
+                    break;
+                case 'files':
+                    html = await this.loadFilesystemPanel();
+                    break;
+                case 'diff':
+                    html = this.renderImageDiffForm();
+                    break;
+                default:
+                    return;
+            }
+            
+            if (this.activeTagPanel === panel) {
+                container.innerHTML = html;
+            }
+        } catch (error) {
+            if (this.activeTagPanel === panel) {
+                container.innerHTML = `
+                    <div class="single-loader error">
+                        <i class="fas fa-exclamation-triangle"></i>
+                        <span>${this.escapeHtml(error.message)}</span>
+                    </div>
+                `;
+            }
+        }
+    }
+
+    // Запрос к API для текущего тега
+    async fetchTagData(endpoint, extraParams = {}) {
+        const params = new URLSearchParams({
+            registry: this.currentRegistry,
+            repository: this.currentRepository,
+            tag: this.currentTag,
+            ...extraParams
+        });
+        
+        const response = await fetch(`/api/v1/${endpoint}?${params.toString()}`);
+        const data = await response.json();
+        
+        if (!response.ok) {
+            throw new Error(data.error || 'Ошибка загрузки данных');
+        }
+        
+        return data;
+    }
+
+    // Панель конфигурации образа
+    async loadImageConfigPanel() {
+        const config = await this.fetchTagData('config');
+        
+        const renderList = (items) => items && items.length > 0
+            ? items.map(item => `<code class="config-value">${this.escapeHtml(item)}</code>`).join('')
+            : '<span class="config-empty">—</span>';
+        
+        const renderRow = (label, value) => `
+            <div class="tag-info-item">
+                <span class="tag-info-label">${label}</span>
+                <span class="tag-info-value config-values">${value}</span>
+            </div>
+        `;
+        
+        const labels = Object.entries(config.labels || {});
+        
+        return `
+            <div class="tag-details-section">
+                <h5><i class="fas fa-cogs"></i> Конфигурация образа</h5>
+                ${renderRow('Entrypoint:', renderList(config.entrypoint))}
+                ${renderRow('Cmd:', renderList(config.cmd))}
+                ${renderRow('WorkingDir:', renderList(config.workingDir ? [config.workingDir] : []))}
+                ${renderRow('User:', renderList(config.user ? [config.user] : []))}
+                ${renderRow('Env:', renderList(config.env))}
+                ${renderRow('Порты:', renderList(config.exposedPorts))}
+                ${renderRow('Volumes:', renderList(config.volumes))}
+                ${renderRow('StopSignal:', renderList(config.stopSignal ? [config.stopSignal] : []))}
+                ${renderRow('Labels:', renderList(labels.map(([key, value]) => `${key}=${value}`)))}
+                ${renderRow('Платформа:', renderList([[config.os, config.architecture, config.variant].filter(Boolean).join('/')]))}
+                ${renderRow('Config digest:', renderList([config.digest]))}
+                ${renderRow(`Diff IDs (${config.diffIds.length}):`, renderList(config.diffIds))}
+            </div>
+        `;
+    }
+
+    // Панель истории сборки и восстановленного Dockerfile
+    async loadImageHistoryPanel() {
+        const history = await this.fetchTagData('history');
+        this.currentDockerfile = history.dockerfile;
+        
+        const entries = history.entries.map((entry, index) => `
+            <div class="history-entry ${entry.emptyLayer ? 'empty' : ''}">
+                <div class="history-entry-header">
+                    <span class="history-step">#${index + 1}</span>
+                    <span class="history-size">${entry.emptyLayer ? 'без слоя' : this.formatSize(entry.layerSize)}</span>
+                </div>
+                <code class="history-command">${this.escapeHtml(entry.instruction || entry.createdBy)}</code>
+                ${entry.layerDigest ? `<code class="platform-digest">${this.escapeHtml(entry.layerDigest)}</code>` : ''}
+                ${entry.comment ? `<div class="history-comment">${this.escapeHtml(entry.comment)}</div>` : ''}
+            </div>
+        `).join('');
+        
+        return `
+            <div class="tag-details-section">
+                <h5><i class="fas fa-file-code"></i> Восстановленный Dockerfile</h5>
+                <div class="dockerfile-container">
+                    <pre class="dockerfile">${this.escapeHtml(history.dockerfile || '# История сборки отсутствует')}</pre>
+                    <button class="copy-button" onclick="app.copyToClipboard(app.currentDockerfile)" title="Скопировать Dockerfile">
+                        <i class="fas fa-copy"></i>
+                    </button>
+                </div>
+            </div>
+            <div class="tag-details-section">
+                <h5><i class="fas fa-history"></i> История слоев (${this.formatSize(history.totalSize)})</h5>
+                ${history.layersMismatch ? `
+                <div class="single-loader error">
+                    <i class="fas fa-exclamation-triangle"></i>
+                    <span>Количество шагов истории не совпадает с количеством слоев</span>
+                </div>
+                ` : ''}
+                ${entries || '<p class="referrers-empty">История сборки отсутствует</p>'}
+            </div>
+        `;
+    }
+
+    // Панель файлов образа: объединенная файловая система или содержимое одного слоя
+    async loadFilesystemPanel() {
+        const filesystem = await this.fetchTagData('filesystem');
+        this.currentFiles = filesystem.entries;
+        this.currentFilesLayer = '';
+        
+        const layerOptions = filesystem.layers.map((layer, index) => `
+            <option value="${this.escapeHtml(layer.digest)}">Слой ${index + 1}: ${this.escapeHtml(layer.digest.substring(7, 19))} (${this.formatSize(layer.size)})</option>
+        `).join('');
+        
+        return `
+            <div class="tag-details-section">
+                <h5><i class="fas fa-folder-open"></i> Файлы образа</h5>
+                <div class="files-toolbar">
+                    <select id="files-layer" class="files-select" onchange="app.selectFilesLayer(this.value)">
+                        <option value="">Все слои (итоговая файловая система)</option>
+                        ${layerOptions}
+                    </select>
+                    <input type="text" id="files-filter" class="files-filter" placeholder="Фильтр по пути..." oninput="app.renderFileList()">
+                </div>
+                <div id="files-list" class="files-list">${this.renderFileRows(this.currentFiles)}</div>
+            </div>
+        `;
+    }
+
+    // Переключение между объединенной файловой системой и отдельным слоем
+    async selectFilesLayer(digest) {
+        const list = document.getElementById('files-list');
+        if (!list) return;
+        
+        list.innerHTML = '<div class="single-loader"><i class="fas fa-spinner fa-spin"></i><span>Загружаем...</span></div>';
+        this.currentFilesLayer = digest;
+        
+        try {
+            if (!digest) {
+                const filesystem = await this.fetchTagData('filesystem');
+                this.currentFiles = filesystem.entries;
+            } else {
+                this.currentFiles = [];
+                await this.streamLayerFiles(digest, () => this.renderFileList());
+            }
+            this.renderFileList();
+        } catch (error) {
+            list.innerHTML = `
+                <div class="single-loader error">
+                    <i class="fas fa-exclamation-triangle"></i>
+                    <span>${this.escapeHtml(error.message)}</span>
+                </div>
+            `;
+        }
+    }
+
+    // Потоковое чтение списка файлов слоя (NDJSON)
+    async streamLayerFiles(digest, onProgress) {
+        const params = new URLSearchParams({
+            registry: this.currentRegistry,
+            repository: this.currentRepository,
+            digest: digest
+        });
+        
+        const response = await fetch(`/api/v1/layer/files?${params.toString()}`);
+        if (!response.ok) {
+            const data = await response.json();
+            throw new Error(data.error || 'Ошибка загрузки слоя');
+        }
+        
+        const reader = response.body.getReader();
+        const decoder = new TextDecoder();
+        let buffer = '';
+        
+        while (true) {
+            const { done, value } = await reader.read();
+            if (done) break;
+            
+            buffer += decoder.decode(value, { stream: true });
+            const lines = buffer.split('\n');
+            buffer = lines.pop();
+            
+            for (const line of lines) {
+                if (!line.trim()) continue;
+                const entry = JSON.parse(line);
+                if (entry.error) {
+                    throw new Error(entry.error);
+                }
+                this.currentFiles.push(entry);
+            }
+            onProgress();
+        }
+    }
+
+    // Перерисовка списка файлов с учетом фильтра
+    renderFileList() {
+        const list = document.getElementById('files-list');
+        if (!list) return;
+        
+        const filterInput = document.getElementById('files-filter');
+        const filter = filterInput ? filterInput.value.trim().toLowerCase() : '';
+        const files = filter
+            ? this.currentFiles.filter(entry => entry.path.toLowerCase().includes(filter))
+            : this.currentFiles;
+        
+        list.innerHTML = this.renderFileRows(files);
+    }
+
+    // Строки списка файлов
+    renderFileRows(files) {
+        if (!files || files.length === 0) {
+            return '<p class="referrers-empty">Файлы не найдены</p>';
+        }
+        
+        const icons = { dir: 'fa-folder', symlink: 'fa-link', hardlink: 'fa-link' };
+        const rows = files.slice(0, this.fileListLimit).map(entry => {
+            let marker = '';
+            if (entry.whiteout) marker = '<span class="badge badge-danger">удален</span>';
+            if (entry.opaque) marker = '<span class="badge badge-warning">opaque</span>';
+            
+            return `
+                <div class="file-row ${entry.whiteout ? 'whiteout' : ''}">
+                    <span class="file-mode">${entry.mode}</span>
+                    <span class="file-path">
+                        <i class="fas ${icons[entry.type] || 'fa-file'}"></i>
+                        ${this.escapeHtml(entry.path)}${entry.linkTarget ? ` → ${this.escapeHtml(entry.linkTarget)}` : ''}
+                        ${marker}
+                    </span>
+                    <span class="file-size">${entry.type === 'file' ? this.formatSize(entry.size) : ''}</span>
+                    ${this.canDownloadFile(entry) ? `
+                    <a class="file-download" href="${this.fileDownloadUrl(entry.path)}" title="Скачать файл">
+                        <i class="fas fa-download"></i>
+                    </a>
+                    ` : ''}
+                </div>
+            `;
+        }).join('');
+        
+        const more = files.length > this.fileListLimit
+            ? `<p class="referrers-empty">Показано ${this.fileListLimit} из ${files.length}, уточните фильтр</p>`
+            : '';
+        
+        return rows + more;
+    }
+
+    // Скачивание доступно для обычных файлов и ссылок итоговой файловой системы
+    canDownloadFile(entry) {
+        return !this.currentFilesLayer && ['file', 'symlink', 'hardlink'].includes(entry.type);
+    }
+
+    // Ссылка на скачивание файла из образа
+    fileDownloadUrl(filePath) {
+        const params = new URLSearchParams({
+            registry: this.currentRegistry,
+            repository: this.currentRepository,
+            tag: this.currentTag,
+            path: filePath
+        });
+        return `/api/v1/file?${params.toString()}`;
+    }
+
+    // Форма сравнения текущего тега с другим тегом или digest
+    renderImageDiffForm() {
+        const options = this.currentTags
+            .filter(tag => tag !== this.currentTag)
+            .map(tag => `<option value="${this.escapeHtml(tag)}"></option>`)
+            .join('');
+        
+        return `
+            <div class="tag-details-section">
+                <h5><i class="fas fa-code-compare"></i> Сравнение образов</h5>
+                <div class="files-toolbar">
+                    <input type="text" id="diff-target" class="files-filter" list="diff-tags" placeholder="Тег или digest для сравнения...">
+                    <datalist id="diff-tags">${options}</datalist>
+                    <label class="diff-option">
+                        <input type="checkbox" id="diff-files"> Файлы
+                    </label>
+                    <button class="btn btn-small" onclick="app.runImageDiff()">
+                        <i class="fas fa-play"></i> Сравнить
+                    </button>
+                </div>
+                <div id="diff-result"></div>
+            </div>
+        `;
+    }
+
+    // Запуск сравнения: текущий тег считается новой версией
+    async runImageDiff() {
+        const target = document.getElementById('diff-target').value.trim();
+        const result = document.getElementById('diff-result');
+        if (!target || !result) return;
+        
+        result.innerHTML = '<div class="single-loader"><i class="fas fa-spinner fa-spin"></i><span>Сравниваем...</span></div>';
+        
+        try {
+            const params = new URLSearchParams({
+                registry: this.currentRegistry,
+                repository: this.currentRepository,
+                from: target,
+                to: this.currentTag,
+                files: document.getElementById('diff-files').checked ? 'true' : 'false'
+            });
+            
+            const response = await fetch(`/api/v1/diff?${params.toString()}`);
+            const data = await response.json();
+            
+            if (!response.ok) {
+                throw new Error(data.error || 'Ошибка сравнения образов');
+            }
+            
+            result.innerHTML = this.renderImageDiff(data);
+        } catch (error) {
+            result.innerHTML = `
+                <div class="single-loader error">
+                    <i class="fas fa-exclamation-triangle"></i>
+                    <span>${this.escapeHtml(error.message)}</span>
+                </div>
+            `;
+        }
+    }
+
+    // Результат сравнения: слои бок о бок, изменения конфигурации и файлов
+    renderImageDiff(diff) {
+        const statusBadges = {
+            shared: '<span class="badge badge-success">общий</span>',
+            added: '<span class="badge badge-warning">новый</span>',
+            removed: '<span class="badge badge-danger">удален</span>'
+        };
+        const sign = (bytes) => (bytes > 0 ? '+' : bytes < 0 ? '−' : '') + this.formatSize(Math.abs(bytes));
+        
+        const renderSide = (image) => `
+            <div class="diff-side">
+                <div class="diff-side-header">
+                    <strong>${this.escapeHtml(image.reference)}</strong>
+                    <span class="platform-size">${this.formatSize(image.size)}</span>
+                </div>
+                <code class="platform-digest">${this.escapeHtml(image.digest)}</code>
+                ${image.layers.map(layer => `
+                    <div class="diff-layer ${layer.status}">
+                        <code class="platform-digest">${this.escapeHtml(layer.digest.substring(7, 19))}</code>
+                        <span class="platform-size">${this.formatSize(layer.size)}</span>
+                        ${statusBadges[layer.status] || ''}
+                    </div>
+                `).join('')}
+            </div>
+        `;
+        
+        const configRows = diff.configChanges.map(change => `
+            <div class="diff-change ${change.change}">
+                <span class="diff-field">${this.escapeHtml(change.field)}${change.key ? ': ' + this.escapeHtml(change.key) : ''}</span>
+                <code class="diff-value">${this.escapeHtml(change.from || '—')}</code>
+                <code class="diff-value">${this.escapeHtml(change.to || '—')}</code>
+            </div>
+        `).join('');
+        
+        let filesSection = '';
+        if (diff.filesCompared) {
+            const files = diff.files || [];
+            const rows = files.slice(0, this.fileListLimit).map(file => {
+                const entry = file.to || file.from;
+                const size = file.change === 'modified' && entry.type === 'file'
+                    ? `${this.formatSize(file.from.size)} → ${this.formatSize(file.to.size)}`
+                    : (entry.type === 'file' ? this.formatSize(entry.size) : '');
+                return `
+                    <div class="file-row diff-file ${file.change}">
+                        <span class="diff-file-marker">${{ added: '+', removed: '−', modified: '~' }[file.change]}</span>
+                        <span class="file-path">${this.escapeHtml(file.path)}</span>
+                        <span class="file-size">${size}</span>
+                    </div>
+                `;
+            }).join('');
+            const more = files.length > this.fileListLimit
+                ? `<p class="referrers-empty">Показано ${this.fileListLimit} из ${files.length}</p>`
+                : '';
+            
+            filesSection = `
+                <h5><i class="fas fa-folder-open"></i> Файлы (${files.length})</h5>
+                <div class="files-list">${rows || '<p class="referrers-empty">Файлы не изменились</p>'}${more}</div>
+            `;
+        }
+        
+        return `
+            <div class="diff-summary">
+                <span>Общих слоев: <strong>${diff.sharedLayers.length}</strong></span>
+                <span>Новых: <strong>${diff.addedLayers.length}</strong> (${this.formatSize(diff.downloadSize)} к загрузке)</span>
+                <span>Удаленных: <strong>${diff.removedLayers.length}</strong></span>
+                <span>Размер: <strong>${sign(diff.sizeDelta)}</strong></span>
+            </div>
+            <div class="diff-sides">
+                ${renderSide(diff.from)}
+                ${renderSide(diff.to)}
+            </div>
+            <h5><i class="fas fa-cogs"></i> Конфигурация</h5>
+            ${configRows || '<p class="referrers-empty">Конфигурация не изменилась</p>'}
+            ${filesSection}
+        `;
+    }
+
+    // Загрузка артефактов, прикрепленных к манифесту
+    async loadReferrers(digest) {
+        const container = document.getElementById('tag-referrers');
+        if (!container) return;
+        
+        container.innerHTML = `
+            <div class="tag-details-section">
+                <h5><i class="fas fa-paperclip"></i> Связанные артефакты</h5>
+                <div class="single-loader"><i class="fas fa-spinner fa-spin"></i><span>Загружаем...</span></div>
+            </div>
+        `;
+        
+        try {
+            const url = `/api/v1/referrers?registry=${encodeURIComponent(this.currentRegistry)}&repository=${encodeURIComponent(this.currentRepository)}&digest=${encodeURIComponent(digest)}`;
+            const response = await fetch(url);
+            const data = await response.json();
+            
+            if (!response.ok) {
+                throw new Error(data.error || 'Ошибка загрузки связанных артефактов');
+            }
+            
+            // Модалка могла быть переоткрыта для другого тега
+            if (!this.currentManifest || this.currentManifest.digest !== digest) return;
+            
+            container.innerHTML = this.renderReferrers(data);
+        } catch (error) {
+            container.innerHTML = `
+                <div class="tag-details-section">
+                    <h5><i class="fas fa-paperclip"></i> Связанные артефакты</h5>
+                    <div class="single-loader error">
+                        <i class="fas fa-exclamation-triangle"></i>
+                        <span>${this.escapeHtml(error.message)}</span>
+                    </div>
+                </div>
+            `;
+        }
+    }
+
+    // Артефакты, сгруппированные по типу
+    renderReferrers(data) {
+        const groups = data.groups || {};
+        const types = Object.keys(groups).sort();
+        
+        if (types.length === 0) {
+            return `
+                <div class="tag-details-section">
+                    <h5><i class="fas fa-paperclip"></i> Связанные артефакты</h5>
+                    <p class="referrers-empty">Подписи, SBOM и attestations не найдены</p>
+                </div>
+            `;
+        }
+        
+        return `
+            <div class="tag-details-section">
+                <h5><i class="fas fa-paperclip"></i> Связанные артефакты (${data.referrers.length})</h5>
+                ${types.map(type => `
+                    <div class="referrers-group">
+                        <div class="referrers-group-title">
+                            <span class="badge badge-success">${this.escapeHtml(type === 'unknown' ? 'Тип не указан' : this.shortArtifactType(type))}</span>
+                            <span class="referrers-group-count">${groups[type].length}</span>
+                        </div>
+                        ${groups[type].map(referrer => {
+                            const created = referrer.annotations && referrer.annotations['org.opencontainers.image.created'];
+                            return `
+                                <div class="layer-item">
+                                    <div class="platform-main">
+                                        <span class="layer-media-type">${this.escapeHtml(referrer.mediaType || '')}</span>
+                                        <span class="platform-size">${created ? new Date(created).toLocaleString('ru-RU') : ''}</span>
+                                    </div>
+                                    <code class="platform-digest">${this.escapeHtml(referrer.digest)}</code>
+                                </div>
+                            `;
+                        }).join('')}
+                    </div>
+                `).join('')}
+            </div>
+        `;
+    }
+
+    // Короткое имя типа артефакта для карточки тега
+    shortArtifactType(artifactType) {
+        if (!artifactType) return '';
+        const knownTypes = {
+            'application/vnd.cncf.helm.config.v1+json': 'Helm chart',
+            'application/vnd.wasm.config.v1+json': 'WASM',
+            'application/vnd.dev.cosign.artifact.sig.v1+json': 'Подпись',
+            'application/spdx+json': 'SBOM (SPDX)',
+            'application/vnd.cyclonedx+json': 'SBOM (CycloneDX)',
+            'application/vnd.in-toto+json': 'Attestation'
+        };
+        return knownTypes[artifactType] || artifactType.replace(/^application\//, '');
+    }
+
+    // Экранирование HTML
+    escapeHtml(text) {
+        const div = document.createElement('div');
+        div.textContent = text;
+        // Кавычки экранируем, чтобы значение можно было подставлять в атрибуты
+        return div.innerHTML.replace(/"/g, '&quot;').replace(/'/g, '&#39;');
+    }
+
+    // Аннотации манифеста
+    renderAnnotations(annotations) {
+        if (!annotations || Object.keys(annotations).length === 0) return '';
+        
+        return `
+            <div class="tag-details-section">
+                <h5><i class="fas fa-sticky-note"></i> Аннотации</h5>
+                ${Object.entries(annotations).map(([key, value]) => `
+                    <div class="tag-info-item">
+                        <span class="tag-info-label">${this.escapeHtml(key)}</span>
+                        <span class="tag-info-value">${this.escapeHtml(value)}</span>
+                    </div>
+                `).join('')}
+            </div>
+        `;
+    }
+
+    // Слои манифеста с их media type
+    renderLayers(layers) {
+        if (!layers || layers.length === 0) return '';
+        
+        return `
+            <div class="tag-details-section">
+                <h5><i class="fas fa-layer-group"></i> Слои (${layers.length})</h5>
+                ${layers.map(layer => {
+                    const title = layer.annotations && layer.annotations['org.opencontainers.image.title'];
+                    return `
+                        <div class="layer-item">
+                            <div class="platform-main">
+                                <span class="layer-media-type">${this.escapeHtml(layer.mediaType || '')}</span>
+                                <span class="platform-size">${this.formatSize(layer.size)}</span>
+                            </div>
+                            ${title ? `<div class="layer-title">${this.escapeHtml(title)}</div>` : ''}
+                            <code class="platform-digest">${this.escapeHtml(layer.digest)}</code>
+                        </div>
+                    `;
+                }).join('')}
+            </div>
+        `;
+    }
+
+    // Список платформ multi-arch образа
+    renderPlatforms(tagName, platforms) {
+        if (!platforms || platforms.length === 0) return '';
+        
+        return `
+            <div class="tag-details-section">
+                <h5><i class="fas fa-layer-group"></i> Платформы (${platforms.length})</h5>
+                <div class="platform-list">
+                    ${platforms.map(platform => {
+                        const name = [platform.platform.os, platform.platform.architecture, platform.platform.variant]
+                            .filter(Boolean).join('/');
+                        const escapedName = this.escapeHtml(name);
+                        return `
+                            <div class="platform-item">
+                                <div class="platform-main">
+                                    <span class="badge badge-primary">${escapedName}</span>
+                                    <span class="platform-size">${this.formatSize(platform.size)}</span>
+                                    <button class="btn btn-secondary btn-small" data-tag="${this.escapeHtml(tagName)}" data-platform="${escapedName}" onclick="app.showPlatformManifest(this.dataset.tag, this.dataset.platform)" title="Подробнее о платформе">
+                                        <i class="fas fa-search-plus"></i>
+                                    </button>
+                                </div>
+                                <code class="platform-digest">${this.escapeHtml(platform.digest)}</code>
+                                <div class="platform-details" id="platform-details-${name.replace(/[^a-zA-Z0-9]/g, '_')}"></div>
+                            </div>
+                        `;
+                    }).join('')}
+                </div>
+            </div>
+        `;
+    }
+
+    // Загрузка манифеста конкретной платформы
+    async showPlatformManifest(tagName, platform) {
+        const container = document.getElementById(`platform-details-${platform.replace(/[^a-zA-Z0-9]/g, '_')}`);
+        if (!container) return;
+        
+        if (container.innerHTML.trim()) {
+            container.innerHTML = '';
+            return;
+        }
+        
+        container.innerHTML = '<div class="single-loader"><i class="fas fa-spinner fa-spin"></i><span>Загружаем манифест...</span></div>';
+        
+        try {
+            const url = `/api/v1/manifest?registry=${encodeURIComponent(this.currentRegistry)}&repository=${encodeURIComponent(this.currentRepository)}&tag=${encodeURIComponent(tagName)}&platform=${encodeURIComponent(platform)}`;
+            const response = await fetch(url);
+            const data = await response.json();
+            
+            if (!response.ok) {
+                throw new Error(data.error || 'Ошибка загрузки манифеста платформы');
+            }
+            
+            container.innerHTML = `
+                <div class="tag-info-item">
+                    <span class="tag-info-label">Media Type:</span>
+                    <span class="tag-info-value">${this.escapeHtml(data.mediaType || 'Не указан')}</span>
+                </div>
+                <div class="tag-info-item">
+                    <span class="tag-info-label">Размер:</span>
+                    <span class="tag-info-value">${this.formatSize(data.size)}</span>
+                </div>
+                ${data.created ? `
+                <div class="tag-info-item">
+                    <span class="tag-info-label">Создан:</span>
+                    <span class="tag-info-value">${new Date(data.created).toLocaleString('ru-RU')}</span>
+                </div>
+                ` : ''}
+            `;
+        } catch (error) {
+            container.innerHTML = '';
+            this.showToast('Ошибка загрузки платформы: ' + error.message, 'error');
+        }
     }
 
     // Функция копирования команды docker pull для тега